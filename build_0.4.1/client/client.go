@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -33,6 +34,8 @@ const (
 	speed    = 4.0
 	scale    = 1.5
 
+	slowWalkFactor = 0.5 // множитель скорости при зажатом Shift (точное позиционирование)
+
 	chatHeightFixed = 400
 )
 
@@ -80,6 +83,9 @@ type Game struct {
 	tileCache      map[int]*ebiten.Image
 	lastMove       time.Time
 	lastKeys       struct{ w, a, s, d bool }
+	lastSentX      float64 // позиция, от которой считается следующее смещение move
+	lastSentY      float64
+	wasMoving      bool // была ли зажата клавиша движения в прошлом кадре
 	debugInfo      string
 	myPlayer       *Player
 	serverTime     time.Time
@@ -274,6 +280,9 @@ func generateMainMenuMap() [][]int {
 }
 
 func main() {
+	windowed := flag.Bool("windowed", true, "запуск в окне; -windowed=false – во весь экран")
+	flag.Parse()
+
 	fmt.Println("=== Мультиплеерная RPG ===")
 
 	var fontFace font.Face
@@ -425,7 +434,7 @@ func main() {
 	ebiten.SetWindowSize(screenW, screenH)
 	ebiten.SetWindowTitle("RPG")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
-	ebiten.SetFullscreen(true)
+	ebiten.SetFullscreen(!*windowed)
 	ebiten.SetTPS(60)
 	ebiten.SetWindowClosingHandled(true)
 
@@ -659,6 +668,8 @@ func (g *Game) handleInit(msg map[string]interface{}) {
 		g.players[id] = player
 		g.myPlayer = player
 		g.name = g.charName
+		g.lastSentX = startX
+		g.lastSentY = startY
 
 		log.Printf("Инициализирован с ID: %s, позиция: %.0f,%.0f, раса: %s", id, startX, startY, race)
 	}
@@ -806,6 +817,8 @@ func (g *Game) handleMoveAck(msg map[string]interface{}) {
 					g.myPlayer.Y = y
 					g.myPlayer.TargetX = x
 					g.myPlayer.TargetY = y
+					g.lastSentX = x
+					g.lastSentY = y
 				}
 			}
 		}
@@ -1190,20 +1203,25 @@ func (g *Game) updateGame() error {
 	sPressed := ebiten.IsKeyPressed(ebiten.KeyS) || ebiten.IsKeyPressed(ebiten.KeyDown)
 	dPressed := ebiten.IsKeyPressed(ebiten.KeyD) || ebiten.IsKeyPressed(ebiten.KeyRight)
 
+	step := speed
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		step *= slowWalkFactor
+	}
+
 	if wPressed {
-		dy -= speed
+		dy -= step
 		moved = true
 	}
 	if sPressed {
-		dy += speed
+		dy += step
 		moved = true
 	}
 	if aPressed {
-		dx -= speed
+		dx -= step
 		moved = true
 	}
 	if dPressed {
-		dx += speed
+		dx += step
 		moved = true
 	}
 
@@ -1245,13 +1263,16 @@ func (g *Game) updateGame() error {
 			dPressed != g.lastKeys.d
 
 		if shouldSend {
+			// отправляем накопленное смещение с прошлой отправки – сервер применит его к своей позиции
 			g.conn.WriteJSON(map[string]any{
 				"action": "move",
-				"dx":     dx,
-				"dy":     dy,
+				"dx":     me.X - g.lastSentX,
+				"dy":     me.Y - g.lastSentY,
 				"x":      me.X,
 				"y":      me.Y,
 			})
+			g.lastSentX = me.X
+			g.lastSentY = me.Y
 			g.lastMove = now
 
 			g.lastKeys.w = wPressed
@@ -1260,12 +1281,26 @@ func (g *Game) updateGame() error {
 			g.lastKeys.d = dPressed
 		}
 	} else {
-		if time.Since(g.lastMove) > 100*time.Millisecond {
-			g.conn.WriteJSON(map[string]any{
-				"action": "position",
-				"x":      me.X,
-				"y":      me.Y,
-			})
+		// клавиши только что отпущены – сразу фиксируем итоговую позицию на сервере
+		if g.wasMoving || time.Since(g.lastMove) > 100*time.Millisecond {
+			if me.X != g.lastSentX || me.Y != g.lastSentY {
+				// досылаем остаток смещения, накопленный после последней отправки
+				g.conn.WriteJSON(map[string]any{
+					"action": "move",
+					"dx":     me.X - g.lastSentX,
+					"dy":     me.Y - g.lastSentY,
+					"x":      me.X,
+					"y":      me.Y,
+				})
+				g.lastSentX = me.X
+				g.lastSentY = me.Y
+			} else {
+				g.conn.WriteJSON(map[string]any{
+					"action": "position",
+					"x":      me.X,
+					"y":      me.Y,
+				})
+			}
 			g.lastMove = time.Now()
 		}
 
@@ -1274,6 +1309,7 @@ func (g *Game) updateGame() error {
 		g.lastKeys.s = false
 		g.lastKeys.d = false
 	}
+	g.wasMoving = moved
 
 	g.mu.Lock()
 	now := time.Now()
@@ -1748,7 +1784,7 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 			meCopy.X, meCopy.Y)
 
 		debugText += fmt.Sprintf(" | HP: %d", meCopy.HP)
-		debugText += "\nF1 - отладка | WASD - движение | T - открыть чат | Esc - закрыть чат/меню | F11 - полноэкранный режим"
+		debugText += "\nF1 - отладка | WASD - движение | Shift - медленный шаг | T - открыть чат | Esc - закрыть чат/меню | F11 - полноэкранный режим"
 
 		lines := strings.Split(debugText, "\n")
 		for i, line := range lines {
@@ -20,6 +20,14 @@ const (
 	tileSize   = 32
 	maxSpeed   = 8.0
 	maxPlayers = 20
+
+	maxMoveStep       = float64(tileSize) // максимальное смещение по оси за одно сообщение move
+	positionTolerance = 4.0               // допустимое расхождение для сообщения position
+
+	ticksPerSecond    = 60   // кадров в секунду у клиента (maxSpeed задан в пикселях за кадр)
+	moveSlack         = 4.0  // запас на неровную доставку сообщений, пиксели
+	moveHistoryLen    = 20   // сколько последних принятых позиций хранить
+	moveHistoryWindow = 1000 // окно проверки средней скорости, мс
 )
 
 type Color struct {
@@ -41,6 +49,14 @@ type Player struct {
 	Color     Color   `json:"color"`
 	LastMove  int64   `json:"-"`
 	LastInput string  `json:"-"`
+
+	History []MoveSample `json:"-"`
+}
+
+// MoveSample – принятая сервером позиция игрока и время её принятия (мс)
+type MoveSample struct {
+	X, Y float64
+	At   int64
 }
 
 type ChatMessage struct {
@@ -85,6 +101,9 @@ var (
 	usedColors  = make(map[uint32]bool)
 	chatHistory []ChatMessage
 	chatMu      sync.RWMutex
+
+	// nowMillis – текущее время в мс для проверки скорости; тесты подставляют свои часы
+	nowMillis = func() int64 { return time.Now().UnixMilli() }
 )
 
 func main() {
@@ -420,14 +439,26 @@ func handlePosition(id string, msg map[string]any) {
 		return
 	}
 
-	if isPositionValid(x, y) {
+	// Сервер авторитетен: принимаем только мелкую доводку позиции,
+	// иначе возвращаем клиенту серверные координаты
+	if math.Abs(x-p.X) <= positionTolerance && math.Abs(y-p.Y) <= positionTolerance &&
+		isPositionValid(x, y) && !collidesWithPlayer(id, x, y) {
 		p.X = x
 		p.Y = y
 		p.TargetX = x
 		p.TargetY = y
+		mu.Unlock()
+		return
 	}
-
+	ackX, ackY := p.X, p.Y
 	mu.Unlock()
+
+	sendToClient(id, map[string]any{
+		"type": "move_ack",
+		"x":    ackX,
+		"y":    ackY,
+		"ts":   time.Now().UnixMilli(),
+	})
 }
 
 func sendToClient(playerID string, msg map[string]any) {
@@ -460,13 +491,14 @@ func sendToClient(playerID string, msg map[string]any) {
 	}
 }
 
+// handleMove – перемещение по смещению dx/dy от последней серверной позиции.
+// Абсолютные координаты клиента не используются: сервер сам пересчитывает
+// позицию, ограничивает шаг по каждой оси и проверяет коллизии.
 func handleMove(id string, msg map[string]any) {
 	dx, ok1 := msg["dx"].(float64)
 	dy, ok2 := msg["dy"].(float64)
-	clientX, ok3 := msg["x"].(float64)
-	clientY, ok4 := msg["y"].(float64)
 
-	if !ok1 || !ok2 || !ok3 || !ok4 {
+	if !ok1 || !ok2 {
 		return
 	}
 
@@ -474,8 +506,8 @@ func handleMove(id string, msg map[string]any) {
 		return
 	}
 
-	dx = math.Max(-maxSpeed, math.Min(maxSpeed, dx))
-	dy = math.Max(-maxSpeed, math.Min(maxSpeed, dy))
+	dx = math.Max(-maxMoveStep, math.Min(maxMoveStep, dx))
+	dy = math.Max(-maxMoveStep, math.Min(maxMoveStep, dy))
 
 	mu.Lock()
 	p, exists := players[id]
@@ -484,55 +516,97 @@ func handleMove(id string, msg map[string]any) {
 		return
 	}
 
-	now := time.Now().UnixMilli()
-
-	nx := clientX
-	ny := clientY
-
-	dxDiff := math.Abs(nx - p.TargetX)
-	dyDiff := math.Abs(ny - p.TargetY)
+	now := nowMillis()
 
-	if dxDiff > 50 || dyDiff > 50 {
-		nx = p.X
-		ny = p.Y
-	} else if isPositionValid(nx, ny) {
-		playerSize := float64(tileSize) * 0.7
-		canMove := true
+	// Ограничение скорости: за прошедшее с последнего хода время
+	// нельзя пройти больше maxSpeed пикселей за кадр
+	dt := now - p.LastMove
+	if p.LastMove == 0 || dt > moveHistoryWindow {
+		dt = moveHistoryWindow
+	}
+	allowed := maxAllowedDistance(dt)
+	if dist := math.Hypot(dx, dy); dist > allowed {
+		dx *= allowed / dist
+		dy *= allowed / dist
+	}
 
-		for _, other := range players {
-			if other.ID != id {
-				distX := nx - other.X
-				distY := ny - other.Y
-				dist := math.Sqrt(distX*distX + distY*distY)
+	canMove := func(x, y float64) bool {
+		return isPositionValid(x, y) && !collidesWithPlayer(id, x, y) && withinSpeedHistory(p, x, y, now)
+	}
 
-				if dist < playerSize {
-					canMove = false
-					break
-				}
-			}
+	nx, ny := p.X+dx, p.Y+dy
+	if !canMove(nx, ny) {
+		// скольжение вдоль препятствия – так же, как это делает клиент
+		nx, ny = p.X, p.Y
+		if dx != 0 && canMove(p.X+dx, p.Y) {
+			nx = p.X + dx
 		}
+		if dy != 0 && canMove(nx, p.Y+dy) {
+			ny = p.Y + dy
+		}
+	}
 
-		if canMove {
-			p.X = nx
-			p.Y = ny
-			p.TargetX = nx
-			p.TargetY = ny
-			p.LastMove = now
+	if nx != p.X || ny != p.Y {
+		p.X = nx
+		p.Y = ny
+		p.TargetX = nx
+		p.TargetY = ny
+		p.LastMove = now
+		p.History = append(p.History, MoveSample{X: nx, Y: ny, At: now})
+		if len(p.History) > moveHistoryLen {
+			p.History = p.History[len(p.History)-moveHistoryLen:]
 		}
 	}
+	ackX, ackY := p.X, p.Y
 
 	mu.Unlock()
 
 	sendToClient(id, map[string]any{
 		"type": "move_ack",
-		"x":    p.X,
-		"y":    p.Y,
+		"x":    ackX,
+		"y":    ackY,
 		"ts":   now,
 	})
 
 	stats.MessagesSent++
 }
 
+// maxAllowedDistance – сколько пикселей можно пройти за dt миллисекунд
+func maxAllowedDistance(dt int64) float64 {
+	return maxSpeed*ticksPerSecond*float64(dt)/1000 + moveSlack
+}
+
+// withinSpeedHistory – не превышает ли переход в (x, y) допустимую скорость
+// относительно самых старых позиций из окна истории. Ловит серию мелких
+// сообщений, каждое из которых по отдельности выглядит честным.
+func withinSpeedHistory(p *Player, x, y float64, now int64) bool {
+	for _, sample := range p.History {
+		dt := now - sample.At
+		if dt > moveHistoryWindow {
+			continue
+		}
+		if math.Hypot(x-sample.X, y-sample.Y) > maxAllowedDistance(dt) {
+			return false
+		}
+	}
+	return true
+}
+
+// collidesWithPlayer – пересекается ли точка с другим игроком (вызывать под mu)
+func collidesWithPlayer(id string, x, y float64) bool {
+	playerSize := float64(tileSize) * 0.7
+	for _, other := range players {
+		if other.ID != id {
+			distX := x - other.X
+			distY := y - other.Y
+			if math.Sqrt(distX*distX+distY*distY) < playerSize {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func handleChat(id string, msg map[string]any) {
 	mu.RLock()
 	p, exists := players[id]
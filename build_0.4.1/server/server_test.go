@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// setupMoveTest готовит пустую карту и одного игрока в точке (x, y) и подменяет часы
+func setupMoveTest(t *testing.T, x, y float64) (*Player, *int64) {
+	t.Helper()
+	gameMap = make([][]int, mapH)
+	for i := range gameMap {
+		gameMap[i] = make([]int, mapW)
+	}
+	p := &Player{ID: "p1", X: x, Y: y, TargetX: x, TargetY: y}
+	players = map[string]*Player{p.ID: p}
+	conns = make(map[string]*Connection)
+
+	clock := int64(1_000_000)
+	saved := nowMillis
+	nowMillis = func() int64 { return clock }
+	t.Cleanup(func() { nowMillis = saved })
+	return p, &clock
+}
+
+func TestHandleMoveClampsStep(t *testing.T) {
+	p, clock := setupMoveTest(t, 500, 500)
+
+	handleMove(p.ID, map[string]any{"dx": 32.0, "dy": 0.0})
+	if p.X != 532 {
+		t.Fatalf("первый шаг: X = %v, want 532", p.X)
+	}
+
+	// через 5 мс допустимо пройти лишь maxAllowedDistance(5) пикселей
+	*clock += 5
+	before := p.X
+	handleMove(p.ID, map[string]any{"dx": 32.0, "dy": 0.0})
+	if step, allowed := p.X-before, maxAllowedDistance(5); step > allowed+1e-9 {
+		t.Errorf("шаг через 5 мс = %v, want <= %v", step, allowed)
+	}
+}
+
+func TestHandleMoveRapidSmallSteps(t *testing.T) {
+	p, clock := setupMoveTest(t, 500, 500)
+
+	// каждый шаг по отдельности укладывается в лимит, но вместе они быстрее maxSpeed
+	const interval = 16
+	step := maxAllowedDistance(interval) - 0.5
+	handleMove(p.ID, map[string]any{"dx": step, "dy": 0.0})
+	// проверяем скорость от первой принятой позиции: с неё начинается история
+	startX, startAt := p.X, *clock
+	rejected := 0
+	for i := 0; i < 40; i++ {
+		*clock += interval
+		before := p.X
+		handleMove(p.ID, map[string]any{"dx": step, "dy": 0.0})
+		if p.X-before < step {
+			rejected++
+		}
+		if elapsed := *clock - startAt; elapsed <= moveHistoryWindow {
+			if dist := p.X - startX; dist > maxAllowedDistance(elapsed)+1e-9 {
+				t.Fatalf("за %d мс пройдено %v пикселей, допустимо %v", elapsed, dist, maxAllowedDistance(elapsed))
+			}
+		}
+	}
+	if rejected == 0 {
+		t.Error("серия мелких шагов не была ни обрезана, ни отклонена")
+	}
+	if p.Y != 500 || math.IsNaN(p.X) {
+		t.Errorf("позиция испорчена: (%v, %v)", p.X, p.Y)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -53,8 +54,15 @@ const (
 	spearButtW    = 8.0
 
 	moveDuration = 0.05
+
+	settingsFile = "settings.json" // файл с сохранёнными настройками клиента
 )
 
+// Settings – настройки клиента, сохраняемые между запусками
+type Settings struct {
+	Fullscreen bool `json:"fullscreen"`
+}
+
 type NetColor struct {
 	R uint8 `json:"r"`
 	G uint8 `json:"g"`
@@ -192,6 +200,8 @@ type Game struct {
 	}
 	fullscreenBtn image.Rectangle
 	backBtn       image.Rectangle
+
+	savedFullscreen bool // полноэкранный режим в файле настроек; -windowed его не меняет
 }
 
 type MainMenuButton struct {
@@ -337,6 +347,11 @@ func generateMainMenuMap() [][]int {
 }
 
 func main() {
+	windowed := flag.Bool("windowed", false, "запуск в окне, независимо от сохранённой настройки")
+	flag.Parse()
+
+	settings := loadSettings()
+
 	fmt.Println("=== Клиент ===")
 
 	var fontFace font.Face
@@ -482,8 +497,9 @@ func main() {
 		glowImage: createGlowImage(36),
 
 		volume:     50,
-		fullscreen: ebiten.IsFullscreen(),
+		fullscreen: settings.Fullscreen && !*windowed,
 	}
+	game.savedFullscreen = settings.Fullscreen
 
 	game.quitConfirmRects.bg = image.Rect(0, 0, 600, 250)
 	game.quitConfirmRects.yes = image.Rect(0, 0, 250, 40)
@@ -942,12 +958,46 @@ func (g *Game) handleChatMessage(msg map[string]interface{}) {
 	g.mu.Unlock()
 }
 
+// loadSettings читает настройки из settingsFile; при ошибке возвращает значения по умолчанию
+// (оконный режим)
+func loadSettings() Settings {
+	var s Settings
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Println("Ошибка чтения настроек:", err)
+		return Settings{}
+	}
+	return s
+}
+
+// saveSettings сохраняет настройки в settingsFile
+func (g *Game) saveSettings() {
+	data, err := json.MarshalIndent(Settings{Fullscreen: g.savedFullscreen}, "", "  ")
+	if err != nil {
+		log.Println("Ошибка сохранения настроек:", err)
+		return
+	}
+	if err := os.WriteFile(settingsFile, data, 0644); err != nil {
+		log.Println("Ошибка сохранения настроек:", err)
+	}
+}
+
+// setFullscreen переключает полноэкранный режим по выбору игрока и запоминает его в настройках
+func (g *Game) setFullscreen(on bool) {
+	g.fullscreen = on
+	g.savedFullscreen = on
+	ebiten.SetFullscreen(on)
+	g.saveSettings()
+}
+
 func (g *Game) Update() error {
 	if ebiten.IsKeyPressed(ebiten.KeyF11) {
 		now := time.Now()
 		if now.Sub(g.lastF11Press) > 200*time.Millisecond {
-			g.fullscreen = !g.fullscreen
-			ebiten.SetFullscreen(g.fullscreen)
+			g.setFullscreen(!g.fullscreen)
 			g.lastF11Press = now
 		}
 	}
@@ -1095,8 +1145,7 @@ func (g *Game) updateSettings() {
 		}
 
 		if pt.In(g.fullscreenBtn) {
-			g.fullscreen = !g.fullscreen
-			ebiten.SetFullscreen(g.fullscreen)
+			g.setFullscreen(!g.fullscreen)
 		}
 
 		if pt.In(g.backBtn) {
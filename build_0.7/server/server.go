@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math"
@@ -21,6 +22,9 @@ const (
 	maxSpeed    = 8.0
 	maxPlayers  = 10
 	turnTimeout = 20 * time.Second
+
+	modeTurn     = "turn"     // пошаговый режим: движение только через turn_action
+	modeRealtime = "realtime" // режим реального времени: действия move/position
 )
 
 type Color struct {
@@ -94,13 +98,22 @@ var (
 	currentTurn   int
 	turnStartTime time.Time
 	turnMu        sync.RWMutex
+
+	gameMode = modeTurn // какой путь перемещения принимает сервер
 )
 
 func main() {
+	flag.StringVar(&gameMode, "mode", modeTurn, "режим игры: turn (пошаговый) или realtime")
+	flag.Parse()
+	if gameMode != modeTurn && gameMode != modeRealtime {
+		log.Fatalf("Неизвестный режим игры %q (ожидается %s или %s)", gameMode, modeTurn, modeRealtime)
+	}
+
 	rand.Seed(time.Now().UnixNano())
 	stats.StartTime = time.Now()
 
 	fmt.Println("=== Сервер ===")
+	fmt.Println("Режим игры:", gameMode)
 	fmt.Println("Генерация карты...")
 	genMap()
 
@@ -398,6 +411,16 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 
 	broadcastToAll()
 
+	// действия не для текущего режима логируются один раз на соединение, дальше молча
+	// отбрасываются: клиент шлёт move каждый кадр и иначе засыпал бы журнал
+	wrongModeLogged := false
+	rejectWrongMode := func(action string) {
+		if !wrongModeLogged {
+			wrongModeLogged = true
+			log.Printf("⚠️ Отклонено действие %q от %s: сервер в режиме %s (дальше без записи в журнал)", action, id, gameMode)
+		}
+	}
+
 	for {
 		var msg map[string]any
 		if err := c.ReadJSON(&msg); err != nil {
@@ -406,14 +429,26 @@ func wsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if action, ok := msg["action"].(string); ok {
+			// движение в реальном времени и пошаговые действия взаимоисключающие:
+			// чужой для текущего режима путь игнорируется, чтобы нельзя было ходить вне очереди
 			switch action {
-			case "move":
-				handleMove(id, msg)
-			case "position":
-				handlePosition(id, msg)
+			case "move", "position":
+				if gameMode != modeRealtime {
+					rejectWrongMode(action)
+					continue
+				}
+				if action == "move" {
+					handleMove(id, msg)
+				} else {
+					handlePosition(id, msg)
+				}
 			case "chat":
 				handleChat(id, msg)
 			case "turn_action":
+				if gameMode != modeTurn {
+					rejectWrongMode(action)
+					continue
+				}
 				handleTurnAction(id, msg)
 			}
 		}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
@@ -11,9 +12,12 @@ import (
 	"log"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +33,8 @@ import (
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/gofont/goregular"
 	"golang.org/x/image/font/opentype"
+
+	"rpg-game/gameserver"
 )
 
 // ==================== КОНСТАНТЫ ====================
@@ -39,8 +45,9 @@ const (
 	screenH  = 1080 // высота окна
 	tileSize = 32   // размер тайла карты в пикселях
 
-	// Чат
-	chatHeightFixed = 400 // высота области чата
+	// Чат (значения по умолчанию, меняются в настройках)
+	defaultChatWidth  = 500 // ширина панели чата
+	defaultChatHeight = 400 // высота панели чата
 
 	// Таймер хода
 	turnTimeout = 20.0 // длительность хода в секундах
@@ -64,6 +71,96 @@ const (
 	// Анимация удара
 	moveDuration       = 0.05 // длительность перемещения (плавное движение)
 	attackAnimDuration = 0.2  // длительность анимации удара (сек)
+
+	// Предсказание собственного хода
+	predictionTimeout      = 0.5          // сколько ждать подтверждения предсказанного хода (сек)
+	correctionDuration     = 0.15         // длительность плавной коррекции позиции (сек)
+	correctionSnapDistance = 2 * tileSize // расхождение, при котором позиция применяется сразу
+
+	// Оповещение о начале своего хода
+	windowTitle     = "RPG"                  // обычный заголовок окна
+	titleBlinkEvery = 600 * time.Millisecond // период мигания заголовка, пока окно не в фокусе
+	sampleRate      = 44100                  // частота дискретизации аудиоконтекста
+
+	// Полоска здоровья
+	playerMaxHP      = 10   // максимальное здоровье (как на сервере)
+	hpLerpFactor     = 0.15 // доля разницы HP, проходимая за кадр при анимации полоски
+	deathFadeSeconds = 0.6  // сколько погибший игрок остаётся на экране, растворяясь
+
+	toastSeconds    = 1.5 // сколько висит всплывающая подсказка
+	announceSeconds = 2.5 // сколько видно объявление сервера (первая кровь, серия)
+
+	// Отклонённый клик
+	rejectSeconds    = 0.4 // сколько виден крест над клеткой, куда не получилось сходить
+	rejectShakeWidth = 4   // амплитуда дрожания креста (пиксели)
+
+	// Предупреждение о низком здоровье
+	lowHPThreshold    = 3 // порог HP, ниже которого появляется виньетка
+	vignetteDownscale = 4 // во сколько раз виньетка меньше экрана (растягивается при отрисовке)
+
+	// Фон главного меню
+	menuDriftSpeed  = 1.1  // скорость прокрутки карты (пиксели за кадр)
+	menuDriftPeriod = 40.0 // период самого медленного поворота направления прокрутки (сек)
+
+	// Отсечение игроков за экраном
+	cullMargin = 120 // запас за краем экрана на имя, оружие и полоску HP
+
+	// Обзор всех игроков (наблюдатель и экран итогов)
+	minCamZoom     = 0.5          // самый мелкий масштаб камеры
+	overviewMargin = 3 * tileSize // запас вокруг крайних игроков, пиксели мира
+
+	// Индикатор направления урона
+	damageIndicatorSeconds = 1.0 // сколько секунд виден индикатор
+	damageIndicatorMargin  = 70  // отступ стрелки от края экрана
+
+	// График времени кадра в отладочном оверлее
+	frameGraphSamples = 120  // сколько последних кадров хранится (~2 с при 60 TPS)
+	frameGraphW       = 240  // ширина графика (2 пикселя на кадр)
+	frameGraphH       = 60   // высота графика
+	frameGraphMaxMS   = 50.0 // время кадра, соответствующее верху графика (мс)
+	normalTPS         = 60   // частота Update в обычном режиме
+
+	// Настройки
+	settingsFile = "settings.json" // файл с сохранёнными настройками клиента
+
+	// Состояние сервера в меню персонажа
+	serverStatusInterval = 3 * time.Second // как часто опрашивать /stats
+
+	// Сервер и возврат в матч
+	defaultServerAddr = "localhost:8080"    // адрес игрового сервера
+	sessionFile       = "last_session.json" // последний персонаж, с которым подключались
+	sessionMaxAge     = 2 * time.Minute     // в течение этого времени в меню предлагается вернуться в матч
+)
+
+// Элементы меню персонажа, между которыми переключается фокус по Tab
+const (
+	charFocusName = iota
+	charFocusRace
+	charFocusWeapon
+	charFocusColor
+	charFocusConnect
+	charFocusCount
+)
+
+// Режимы действия в свой ход, переключаемые колесом мыши
+const (
+	actionAuto   = iota // атака по врагу под курсором, иначе перемещение
+	actionMove          // только перемещение, даже рядом с врагом
+	actionAttack        // только атака
+	actionSkip          // пропуск хода кликом
+	actionHeal          // лечение вместо действия
+	actionModeCount
+)
+
+// actionModeNames – ключи подписей режимов для индикатора (см. translations)
+var actionModeNames = [actionModeCount]string{"action.auto", "action.move", "action.attack", "action.skip", "action.heal"}
+
+// Этапы подключения к серверу (для экрана загрузки)
+const (
+	phaseConnecting = iota // соединение открыто, ждём "init"
+	phaseAwaitMap          // "init" получен, ждём карту
+	phaseAwaitState        // карта получена, ждём первое состояние
+	phasePlaying           // всё получено, идёт игра
 )
 
 // ==================== СТРУКТУРЫ ====================
@@ -76,16 +173,76 @@ type NetColor struct {
 	A uint8 `json:"a"`
 }
 
+// DamageIndicator – стрелка у края экрана, указывающая на того, кто нанёс урон
+type DamageIndicator struct {
+	Angle float64   // направление от меня на атакующего (радианы)
+	Start time.Time // время получения урона
+}
+
+// LastSession – данные последнего подключения для кнопки «Вернуться в матч»
+type LastSession struct {
+	Server string   `json:"server"`
+	Name   string   `json:"name"`
+	Race   string   `json:"race"`
+	Weapon string   `json:"weapon"`
+	Color  NetColor `json:"color"`
+	Time   int64    `json:"time"` // unix-время подключения (сек)
+}
+
+// Settings – настройки клиента, сохраняемые между запусками
+type Settings struct {
+	Volume         int     `json:"volume"`
+	Fullscreen     bool    `json:"fullscreen"`
+	ConfirmActions bool    `json:"confirm_actions"`
+	ShowGrid       bool    `json:"show_grid"`
+	ShowSpawnZone  bool    `json:"show_spawn_zone"`
+	ChatWidth      int     `json:"chat_width"`
+	ChatHeight     int     `json:"chat_height"`
+	ChatCorner     string  `json:"chat_corner"`     // "left" или "right" – нижний угол экрана
+	Language       string  `json:"language"`        // язык интерфейса ("ru" / "en")
+	UIScale        float64 `json:"ui_scale"`        // множитель размера шрифтов (из uiScalePresets)
+	LeftHanded     bool    `json:"left_handed"`     // зеркальное расположение боковых элементов интерфейса
+	ChatTimestamps bool    `json:"chat_timestamps"` // показывать время сообщений чата
+	HideSpectators bool    `json:"hide_spectators"` // скрывать сообщения зрителей, пока сам в игре
+	ColoredNames   bool    `json:"colored_names"`   // имена над игроками – цветом игрока, как ники в чате
+	Performance    bool    `json:"performance"`     // режим для слабых машин: без необязательных эффектов
+	WindowWidth    int     `json:"window_width"`    // размер окна в оконном режиме (из windowSizePresets)
+	WindowHeight   int     `json:"window_height"`
+}
+
+// uiScalePresets – масштабы интерфейса, перебираемые кнопкой в настройках.
+// Раскладка экранов не масштабируется, поэтому крупнее 1.25 подписи (шрифт 40 px)
+// перестают помещаться в кнопки высотой 40 px и шириной 600 px
+var uiScalePresets = []float64{1, 1.1, 1.25}
+
+// windowSizePresets – размеры окна в оконном режиме, перебираемые кнопкой в настройках
+var windowSizePresets = [][2]int{{1280, 720}, {1600, 900}, {screenW, screenH}}
+
+// chatSizePresets – размеры панели чата, перебираемые кнопкой в настройках
+var chatSizePresets = [][2]int{{400, 300}, {defaultChatWidth, defaultChatHeight}, {700, 600}}
+
+// ServerStatus – сводка с /stats, показываемая в меню персонажа
+type ServerStatus struct {
+	Players    int    `json:"players"`
+	MaxPlayers int    `json:"max_players"`
+	Uptime     string `json:"uptime"`
+}
+
 // Player – данные игрока (клиентская копия)
 type Player struct {
 	ID          string  // уникальный идентификатор
 	Name        string  // имя игрока
-	Race        string  // раса ("human" или "cat")
+	Race        string  // раса ("human", "cat", "dog" или "bird")
 	Weapon      string  // оружие ("sword" / "spear")
 	X, Y        float64 // текущие координаты (интерполированные)
 	TargetX     float64 // целевые координаты (от сервера)
 	TargetY     float64
 	HP          int           // здоровье
+	Poison      int           // сила отравления (0 – не отравлен)
+	Defending   bool          // игрок в защите до начала своего хода
+	Overwatch   bool          // игрок в дозоре: ударит того, кто шагнёт в зону его удара
+	Reconnect   bool          // игрок потерял соединение, сервер держит его место
+	DisplayHP   float64       // отображаемое здоровье (плавно догоняет HP)
 	Color       NetColor      // цвет игрока
 	Image       *ebiten.Image // кэшированное изображение цветного квадрата
 	LastUpdate  time.Time     // время последнего обновления от сервера
@@ -99,49 +256,115 @@ type Player struct {
 	MoveStartY    float64   // начальная позиция Y
 	MoveEndX      float64   // конечная позиция X
 	MoveEndY      float64   // конечная позиция Y
+	MoveSeconds   float64   // длительность текущего перемещения (0 – moveDuration)
 
 	// Поля для анимации удара
 	AttackAnimStart    time.Time // время начала анимации
 	AttackAnimTargetID string    // ID цели
 	AttackAnimType     string    // тип оружия ("sword" / "spear")
 	AttackAnimProgress float64   // прогресс 0..1
+
+	// Исчезновение после смерти/выхода
+	FadeStart time.Time // когда игрок пропал из состояния сервера
 }
 
 // ChatMessage – сообщение чата
 type ChatMessage struct {
-	From  string   // отправитель
-	Text  string   // текст сообщения
-	Time  int64    // временная метка (мс)
-	Color NetColor // цвет отправителя
+	From      string   // отправитель
+	Text      string   // текст сообщения
+	Time      int64    // временная метка (мс)
+	Color     NetColor // цвет отправителя
+	Spectator bool     // сообщение из канала зрителей
+}
+
+// raceDrawFunc рисует отличительные черты расы поверх квадрата игрока
+type raceDrawFunc func(g *Game, screen *ebiten.Image, cx, cy float64, col NetColor, scale float64)
+
+// RaceInfo – описание расы: подпись в меню и отрисовка отличительных черт
+type RaceInfo struct {
+	ID    string       // идентификатор для сервера
+	Label string       // ключ надписи на кнопке (см. translations)
+	Draw  raceDrawFunc // украшение (nil – без украшений)
+}
+
+// races – все доступные расы; чтобы добавить новую, достаточно дописать строку сюда и на сервер
+var races = []RaceInfo{
+	{ID: "human", Label: "race.human"},
+	{ID: "cat", Label: "race.cat", Draw: (*Game).drawCatEarsScaled},
+	{ID: "dog", Label: "race.dog", Draw: (*Game).drawDogEarsScaled},
+	{ID: "bird", Label: "race.bird", Draw: (*Game).drawBeakScaled},
+}
+
+// TurnAction – действие хода, выбранное кликом (ход или атака)
+type TurnAction struct {
+	Kind     string // "move", "attack", "skip", "heal", "defend" или "overwatch"
+	TileX    int    // клетка, по которой кликнули
+	TileY    int
+	TargetID string // цель атаки (для "attack")
+}
+
+// ScoreEntry – строка итоговой таблицы матча
+type ScoreEntry struct {
+	Name   string // имя игрока
+	Kills  int    // убийства
+	Deaths int    // смерти
 }
 
+// MatchSummary – итоги матча из сообщения "game_over"
+type MatchSummary struct {
+	Winner     string       // имя победителя ("" – победителя нет)
+	WinnerID   string       // ID победителя
+	Duration   float64      // длительность матча в секундах
+	Scoreboard []ScoreEntry // итоговая таблица
+}
+
+// rejoinButtonText – ключ надписи кнопки возврата в последний матч
+const rejoinButtonText = "menu.rejoin"
+
+// serverAddr – адрес сервера, к которому подключается клиент: defaultServerAddr
+// или локальный сервер тренировки
+var serverAddr = defaultServerAddr
+
+// practiceAddr – адрес сервера тренировки, запущенного в этом процессе; пусто – не запускался
+var practiceAddr string
+
 // MainMenuButton – структура кнопки главного меню
 type MainMenuButton struct {
-	Text   string        // надпись
+	Text   string        // ключ надписи (см. translations)
 	Action func(g *Game) // действие при нажатии
 }
 
 // Game – главная структура игры, реализует интерфейс ebiten.Game
 type Game struct {
 	// Состояние приложения
-	state string // "mainmenu", "character", "game", "settings"
+	state string // "mainmenu", "character", "game", "settings", "summary"
 
 	// Сетевые поля
 	mu             sync.RWMutex
 	conn           *websocket.Conn
 	id             string
 	players        map[string]*Player
+	fadingPlayers  map[string]*Player // игроки, пропавшие из состояния; дорисовываются, пока растворяются
 	gameMap        [][]int
+	spawnZone      image.Rectangle // безопасная зона в клетках (Max не включается)
 	camX, camY     float64
+	camZoom        float64       // масштаб камеры: 1 – обычный, меньше – обзор всех игроков
+	worldLayer     *ebiten.Image // слой, в который при camZoom < 1 рисуется мир перед уменьшением
 	ready          bool
 	connected      bool
 	tileCache      map[int]*ebiten.Image
 	lastMove       time.Time
 	myPlayer       *Player
-	disconnectTime time.Time
 	connectionLost bool
+	lastStateTime  time.Time              // время получения последнего сообщения "state"
+	pendingState   map[string]interface{} // последнее полученное, но ещё не применённое "state"
+	connPhase      int                    // этап подключения (phaseConnecting … phasePlaying)
 	lastF1Press    time.Time
+	lastF2Press    time.Time
 	lastF11Press   time.Time
+	uncapped       bool // вертикальная синхронизация выключена, Update вызывается каждый кадр (для замеров)
+	showGrid       bool // сетка по границам тайлов
+	lastGridToggle time.Time
 
 	// Анимация оружия – только для текущего игрока
 	mySwordCurrentAngle float64
@@ -151,6 +374,7 @@ type Game struct {
 	chatOpen         bool
 	chatBuffer       string
 	chatHistory      []ChatMessage
+	chatServer       string // сервер, с которого получена chatHistory
 	chatLastToggle   time.Time
 	lastChatMessage  time.Time
 	chatCursor       bool
@@ -158,7 +382,16 @@ type Game struct {
 	chatJustOpened   bool
 	chatScrollOffset int
 	chatLineHeight   int
+	chatWidth        int
+	chatHeight       int
+	chatCorner       string // "left" / "right"
 	chatUserScrolled bool
+	leftHanded       bool // интерфейс для левши: чат, таймер и боковые панели отражены по горизонтали
+	chatTimestamps   bool // время ЧЧ:ММ перед сообщениями чата
+	hideSpectators   bool // не показывать чат зрителей, пока игрок жив
+	coloredNames     bool // имена над игроками цветом игрока с тёмной обводкой
+	perfMode         bool // без плавного движения, подсветки, виньетки, растворения и дрейфа фона меню
+	windowW, windowH int  // размер окна в оконном режиме
 
 	// Меню создания персонажа
 	charName           string
@@ -172,20 +405,37 @@ type Game struct {
 	charNameEdit       bool
 	charColorButtons   []image.Rectangle
 	charConnectButton  image.Rectangle
-	charRaceHumanBtn   image.Rectangle
-	charRaceCatBtn     image.Rectangle
+	charRaceBtns       []image.Rectangle
 	charWeaponSwordBtn image.Rectangle
 	charWeaponSpearBtn image.Rectangle
 	charNameInputRect  image.Rectangle
 	charPreviewImg     *ebiten.Image
 	colorsFetched      bool
+	practice           bool                // подключение к локальному серверу тренировки
+	charFocus          int                 // элемент с фокусом клавиатуры (charFocusName …)
+	charPrevKeys       map[ebiten.Key]bool // состояние клавиш в прошлом кадре
+	previewWeapon      string              // оружие, которое держит персонаж на предпросмотре
+	previewSwingAt     time.Time           // когда оружие на предпросмотре сменилось (начало взмаха)
+
+	// Состояние сервера (опрос /stats в меню персонажа)
+	serverStatus         ServerStatus
+	serverStatusOK       bool // последний запрос успешен
+	serverStatusFetched  bool // получен хотя бы один ответ
+	serverStatusFetching bool
+	lastStatusFetch      time.Time
 
 	// Главное меню
 	mainMenuMap         [][]int
 	mainMenuOffsetX     float64
 	mainMenuOffsetY     float64
+	mainMenuDriftTime   float64 // сколько секунд прокручивается фон (задаёт направление дрейфа)
 	mainMenuButtons     []MainMenuButton
 	mainMenuButtonRects []image.Rectangle
+	mainMenuSelected    int         // кнопка, выбранная с клавиатуры или наведением мыши
+	mainMenuLastCursor  image.Point // позиция курсора в прошлом кадре (чтобы замечать движение мыши)
+	prevUpPressed       bool
+	prevDownPressed     bool
+	prevEnterPressed    bool
 
 	// Подтверждение выхода
 	showQuitConfirm  bool
@@ -198,21 +448,90 @@ type Game struct {
 	prevEscPressed bool
 	prevLeftMouse  bool
 
+	// Экран потери соединения
+	lostScreenRects struct {
+		reconnect image.Rectangle
+		menu      image.Rectangle
+	}
+
 	// Экран смерти
 	showDeathScreen  bool
 	deathScreenRects struct {
-		bg image.Rectangle
-		ok image.Rectangle
+		bg       image.Rectangle
+		ok       image.Rectangle
+		spectate image.Rectangle
+	}
+
+	// Режим наблюдателя (после смерти)
+	spectating        bool
+	spectatorFollowID string // за кем следит камера; "" – за игроком, чей сейчас ход
+	spectatorFreeCam  bool   // свободная камера (WASD)
+	spectatorOverview bool   // камера держит в кадре всех живых игроков
+	prevArrowLeft     bool
+	prevArrowRight    bool
+	prevFKey          bool
+	prevZKey          bool
+
+	// Итоги матча
+	summary      MatchSummary
+	summaryRects struct {
+		again image.Rectangle
+		menu  image.Rectangle
 	}
 
 	// Пошаговый режим
-	currentTurn  string
-	turnTimeLeft float64
-	myTurn       bool
+	currentTurn       string
+	turnTimeLeft      float64   // последнее значение таймера хода от сервера
+	turnTimeSyncedAt  time.Time // когда оно получено; между состояниями таймер убывает локально
+	waitingForPlayers bool      // сервер остановил таймер: соперников нет
+	turnOrder         []string  // очередь ходов (ID) из последнего состояния
+	myTurn            bool
+	turnStartedAt     int64 // начало текущего хода по часам сервера (мс)
+	actedThisTurn     bool  // действие этого хода уже отправлено – второе сервер бы отклонил
+
+	// Предсказанная позиция своего игрока, ожидающая подтверждения сервера
+	predicting             bool
+	predictedX, predictedY float64
+	predictedAt            time.Time
+
+	// Подтверждение действий: первый клик выбирает цель, второй – подтверждает
+	pendingAction  *TurnAction
+	prevRightMouse bool
+
+	actionMode int // выбранный колесом мыши режим действия (actionAuto...)
+
+	// Всплывающая подсказка, когда клик в свой ход ничего не сделал
+	toastText string
+	toastTime time.Time
+
+	// Объявление сервера крупным шрифтом (первая кровь, серия убийств)
+	announceText string
+	announceAt   time.Time
+
+	// Клетка последнего отклонённого клика (красный крест над ней)
+	rejectedTileX, rejectedTileY int
+	rejectedAt                   time.Time
 
 	// Подсветка врага при наведении
-	hoveredEnemyID string
-	glowImage      *ebiten.Image
+	hoveredEnemyID  string
+	cursorKind      string // что сделает клик под курсором в мой ход: "attack", "move", "heal", "skip", "forbidden"; пусто – обычный курсор
+	cursorHidden    bool   // системный курсор скрыт, рисуется свой
+	cornerBlockedID string // враг под курсором, до которого не достать через угол препятствия
+	glowImage       *ebiten.Image
+
+	// Правила диагональной атаки, присланные сервером в init
+	diagonalMelee bool
+	blockCorners  bool
+
+	// Урон и дальность моего оружия по данным сервера (из init; 0 – сервер не прислал)
+	weaponDamage int
+	weaponRange  int
+
+	// Предупреждение о низком здоровье
+	lowHPVignette *ebiten.Image // кэшированная красная виньетка по краям экрана
+
+	// Индикаторы направления полученного урона
+	damageIndicators []DamageIndicator
 
 	// Настройки
 	volume       int
@@ -224,6 +543,32 @@ type Game struct {
 	fullscreenBtn        image.Rectangle
 	backBtn              image.Rectangle
 	lastFullscreenToggle time.Time
+	savedFullscreen      bool // полноэкранный режим в файле настроек; -windowed его не меняет
+	confirmActions       bool // требовать повторный клик для подтверждения хода
+	confirmActionsBtn    image.Rectangle
+	lastConfirmToggle    time.Time
+	showSpawnZone        bool // подсвечивать безопасную зону появления
+	spawnZoneBtn         image.Rectangle
+	lastSpawnZoneToggle  time.Time
+	chatSizeBtn          image.Rectangle
+	chatCornerBtn        image.Rectangle
+	lastChatSettingClick time.Time
+	languageBtn          image.Rectangle
+	lastLanguageToggle   time.Time
+	uiScaleBtn           image.Rectangle
+	lastUIScaleToggle    time.Time
+	leftHandedBtn        image.Rectangle
+	lastLeftHandedToggle time.Time
+	chatTimeBtn          image.Rectangle
+	lastChatTimeToggle   time.Time
+	spectatorChatBtn     image.Rectangle
+	lastSpectatorToggle  time.Time
+	coloredNamesBtn      image.Rectangle
+	lastNamesToggle      time.Time
+	performanceBtn       image.Rectangle
+	lastPerfToggle       time.Time
+	windowSizeBtn        image.Rectangle
+	lastWindowSizeToggle time.Time
 
 	// Шрифты
 	fontFace     font.Face
@@ -231,12 +576,304 @@ type Game struct {
 	logoFontFace font.Face
 	nameFontFace font.Face
 	showDebug    bool
+	uiScale      float64 // множитель размера шрифтов
+
+	// Время кадра для отладочного графика (кольцевой буфер)
+	frameTimes   [frameGraphSamples]float64 // длительность кадров в мс
+	frameTimePos int                        // куда записать следующий кадр
+	lastFrameAt  time.Time
 
 	// Музыка
-	audioContext   *audio.Context
-	menuMusic      *audio.Player
-	gameMusic      *audio.Player
-	currentMusic   string // "menu", "game" или "none"
+	audioContext *audio.Context
+	menuMusic    *audio.Player
+	gameMusic    *audio.Player
+	currentMusic string        // "menu", "game" или "none"
+	heartbeat    *audio.Player // сердцебиение при низком здоровье
+	turnChime    *audio.Player // сигнал о начале своего хода
+
+	// Оповещение о своём ходе
+	turnAlertPending bool      // ход только что перешёл ко мне (выставляет handleState под g.mu)
+	titleBlinking    bool      // заголовок окна мигает, пока окно не в фокусе
+	titleBlinkOn     bool      // сейчас показан заголовок-оповещение
+	titleBlinkAt     time.Time // последняя смена заголовка
+}
+
+// ==================== ЛОКАЛИЗАЦИЯ ====================
+
+// languages – языки интерфейса в порядке переключения кнопкой в настройках
+var languages = []string{"ru", "en"}
+
+// uiLang – текущий язык интерфейса, задаётся из настроек
+var uiLang = "ru"
+
+// translations – строки интерфейса: язык -> ключ -> текст.
+// Строки с %d/%s – шаблоны для fmt.Sprintf
+var translations = map[string]map[string]string{
+	"ru": {
+		"language.name": "Русский",
+
+		"menu.play":     "Играть",
+		"menu.practice": "Тренировка",
+		"menu.settings": "Настройки",
+		"menu.exit":     "Выход",
+		"menu.rejoin":   "Вернуться в матч",
+
+		"race.human": "Человек",
+		"race.cat":   "Кот",
+		"race.dog":   "Пёс",
+		"race.bird":  "Птица",
+
+		"char.title":    "Создание персонажа",
+		"char.name":     "Имя:",
+		"char.race":     "Раса:",
+		"char.weapon":   "Оружие:",
+		"char.color":    "Цвет:",
+		"char.online":   "Игроков онлайн: %d/%d",
+		"char.full":     " (сервер заполнен)",
+		"char.uptime":   "Время работы: %s",
+		"char.offline":  "Сервер недоступен",
+		"char.checking": "Проверка сервера...",
+		"char.connect":  "Подключиться",
+		"char.error":    "Ошибка: %s",
+		"char.no_color": "Цвет прошлой сессии недоступен, выберите другой",
+		"char.conn_err": "Ошибка подключения: %s",
+		"char.send_err": "Ошибка отправки данных: %s",
+		"char.keys":     "Tab – следующий элемент | 1/2 – раса | 3/4 – меч/копьё | стрелки – выбор | Enter – подключиться",
+
+		"settings.title":          "Настройки",
+		"settings.volume":         "Громкость музыки: %d%%",
+		"settings.windowed":       "Оконный режим",
+		"settings.fullscreen":     "Полноэкранный режим",
+		"settings.window_size":    "Окно: %dx%d",
+		"settings.confirm_off":    "Подтверждение действий: выкл",
+		"settings.confirm_on":     "Подтверждение действий: вкл",
+		"settings.spawn_hidden":   "Безопасная зона: скрыта",
+		"settings.spawn_visible":  "Безопасная зона: видна",
+		"settings.chat_size":      "Размер чата: %dx%d",
+		"settings.chat_left":      "Чат: слева внизу",
+		"settings.chat_right":     "Чат: справа внизу",
+		"settings.language":       "Язык: %s",
+		"settings.ui_scale":       "Масштаб шрифтов: %d%%",
+		"settings.hud_right":      "Интерфейс: обычный",
+		"settings.hud_left":       "Интерфейс: для левши",
+		"settings.chat_time_on":   "Время в чате: показывать",
+		"settings.chat_time_off":  "Время в чате: скрыто",
+		"settings.spectators_on":  "Чат зрителей: показывать",
+		"settings.spectators_off": "Чат зрителей: скрыт",
+		"settings.names_colored":  "Имена: цветом игрока",
+		"settings.names_plain":    "Имена: белые",
+		"settings.perf_on":        "Режим производительности: вкл",
+		"settings.perf_off":       "Режим производительности: выкл",
+		"chat.spectator":          "[зритель] ",
+		"settings.back":           "Назад",
+
+		"death.title":    "Вы погибли!",
+		"death.menu":     "В меню",
+		"death.spectate": "Наблюдать",
+
+		"summary.title":     "Матч окончен",
+		"summary.no_winner": "Победителя нет",
+		"summary.winner":    "Победитель: %s",
+		"summary.you":       " (вы!)",
+		"summary.duration":  "Длительность: %02d:%02d",
+		"summary.player":    "Игрок",
+		"summary.kills":     "Убийства",
+		"summary.deaths":    "Смерти",
+		"summary.again":     "Играть снова",
+		"summary.menu":      "В меню",
+
+		"quit.title": "Сдаёшься?",
+		"quit.menu":  "Главное меню",
+		"quit.no":    "Нет",
+		"quit.exit":  "Выйти",
+
+		"game.lost":         "❌ Потеряно соединение с сервером",
+		"game.reconnect":    "Переподключиться",
+		"game.to_menu":      "В меню",
+		"game.help":         "F1 - отладка | ЛКМ - движение/атака | Колесо - режим действия | Space - пропустить ход | B - защита | V - дозор | G - сетка | T - открыть чат | Esc - закрыть чат/меню | F11 - полноэкранный режим",
+		"turn.yours":        "ВАШ ХОД",
+		"turn.other":        "Ход игрока",
+		"turn.waiting":      "Ждём игроков",
+		"turn.reconnecting": "(переподключается)",
+		"pending.hint":      "ЛКМ ещё раз – подтвердить, ПКМ/Esc – отмена",
+		"spectate.free":     "Свободная камера (WASD) | F – следить за игроком",
+		"spectate.follow":   "Наблюдение: %s | ←/→ или клик – сменить игрока | F – свободная камера | Z – все игроки",
+		"spectate.overview": "Обзор: все игроки | ←/→ или клик – следить за игроком | Z – выйти из обзора",
+
+		"action.label":  "Действие: %s (колесо мыши)",
+		"action.auto":   "Авто",
+		"action.move":   "Перемещение",
+		"action.attack": "Атака",
+		"action.skip":   "Пропуск",
+		"action.heal":   "Лечение",
+
+		"loading.connecting": "Подключение к серверу",
+		"loading.map":        "Загрузка карты",
+		"loading.state":      "Ожидание состояния",
+
+		"toast.no_target":    "Нет цели",
+		"toast.out_of_reach": "Враг вне досягаемости",
+		"toast.occupied":     "Клетка занята",
+		"toast.too_far":      "Можно пройти только на соседнюю клетку",
+		"toast.blocked":      "Сюда не пройти",
+		"toast.map_changed":  "Карта изменилась",
+		"toast.no_overwatch": "В дозор можно встать только с копьём",
+		"toast.reaction":     "Удар из дозора!",
+
+		"tile.grass":   "трава",
+		"tile.water":   "вода",
+		"tile.stone":   "камень",
+		"tile.unknown": "неизвестно (%d)",
+
+		"debug.stats":        "FPS: %.1f | Игроков: %d | X: %.0f Y: %.0f | Оружие: %s",
+		"debug.updated":      " | Последнее обновление: %d мс назад",
+		"debug.uncapped":     "Без ограничения кадров (F2): TPS %.0f",
+		"debug.vsync":        "VSync, TPS %d (F2 – снять ограничение)",
+		"debug.server_rules": "Сервер: урон %d, дальность %d",
+		"debug.client_range": " (клиент считает %d!)",
+		"debug.no_rules":     "Сервер не прислал урон и дальность оружия",
+		"debug.off_map":      "за картой",
+		"debug.cursor":       "Курсор: X: %.0f Y: %.0f | Тайл: %d, %d | Тип: %s",
+		"debug.frame_worst":  "Кадр: худший %.1f мс",
+	},
+	"en": {
+		"language.name": "English",
+
+		"menu.play":     "Play",
+		"menu.practice": "Practice",
+		"menu.settings": "Settings",
+		"menu.exit":     "Exit",
+		"menu.rejoin":   "Rejoin match",
+
+		"race.human": "Human",
+		"race.cat":   "Cat",
+		"race.dog":   "Dog",
+		"race.bird":  "Bird",
+
+		"char.title":    "Create character",
+		"char.name":     "Name:",
+		"char.race":     "Race:",
+		"char.weapon":   "Weapon:",
+		"char.color":    "Color:",
+		"char.online":   "Players online: %d/%d",
+		"char.full":     " (server full)",
+		"char.uptime":   "Uptime: %s",
+		"char.offline":  "Server unavailable",
+		"char.checking": "Checking server...",
+		"char.connect":  "Connect",
+		"char.error":    "Error: %s",
+		"char.no_color": "The color from your last session is taken, choose another",
+		"char.conn_err": "Connection error: %s",
+		"char.send_err": "Failed to send data: %s",
+		"char.keys":     "Tab – next field | 1/2 – race | 3/4 – sword/spear | arrows – choose | Enter – connect",
+
+		"settings.title":          "Settings",
+		"settings.volume":         "Music volume: %d%%",
+		"settings.windowed":       "Windowed",
+		"settings.fullscreen":     "Fullscreen",
+		"settings.window_size":    "Window: %dx%d",
+		"settings.confirm_off":    "Confirm actions: off",
+		"settings.confirm_on":     "Confirm actions: on",
+		"settings.spawn_hidden":   "Safe zone: hidden",
+		"settings.spawn_visible":  "Safe zone: shown",
+		"settings.chat_size":      "Chat size: %dx%d",
+		"settings.chat_left":      "Chat: bottom left",
+		"settings.chat_right":     "Chat: bottom right",
+		"settings.language":       "Language: %s",
+		"settings.ui_scale":       "Font scale: %d%%",
+		"settings.hud_right":      "HUD: standard",
+		"settings.hud_left":       "HUD: left-handed",
+		"settings.chat_time_on":   "Chat timestamps: shown",
+		"settings.chat_time_off":  "Chat timestamps: hidden",
+		"settings.spectators_on":  "Spectator chat: shown",
+		"settings.spectators_off": "Spectator chat: hidden",
+		"settings.names_colored":  "Names: player colour",
+		"settings.names_plain":    "Names: white",
+		"settings.perf_on":        "Performance mode: on",
+		"settings.perf_off":       "Performance mode: off",
+		"chat.spectator":          "[spectator] ",
+		"settings.back":           "Back",
+
+		"death.title":    "You died!",
+		"death.menu":     "Menu",
+		"death.spectate": "Spectate",
+
+		"summary.title":     "Match over",
+		"summary.no_winner": "No winner",
+		"summary.winner":    "Winner: %s",
+		"summary.you":       " (you!)",
+		"summary.duration":  "Duration: %02d:%02d",
+		"summary.player":    "Player",
+		"summary.kills":     "Kills",
+		"summary.deaths":    "Deaths",
+		"summary.again":     "Play again",
+		"summary.menu":      "Main menu",
+
+		"quit.title": "Give up?",
+		"quit.menu":  "Main menu",
+		"quit.no":    "No",
+		"quit.exit":  "Quit",
+
+		"game.lost":         "❌ Lost connection to the server",
+		"game.reconnect":    "Reconnect",
+		"game.to_menu":      "To menu",
+		"game.help":         "F1 - debug | LMB - move/attack | Wheel - action mode | Space - skip turn | B - defend | V - overwatch | G - grid | T - open chat | Esc - close chat/menu | F11 - fullscreen",
+		"turn.yours":        "YOUR TURN",
+		"turn.other":        "Turn of",
+		"turn.waiting":      "Waiting...",
+		"turn.reconnecting": "(reconnecting)",
+		"pending.hint":      "LMB again – confirm, RMB/Esc – cancel",
+		"spectate.free":     "Free camera (WASD) | F – follow a player",
+		"spectate.follow":   "Spectating: %s | ←/→ or click – switch player | F – free camera | Z – all players",
+		"spectate.overview": "Overview: all players | ←/→ or click – follow a player | Z – leave overview",
+
+		"action.label":  "Action: %s (mouse wheel)",
+		"action.auto":   "Auto",
+		"action.move":   "Move",
+		"action.attack": "Attack",
+		"action.skip":   "Skip",
+		"action.heal":   "Heal",
+
+		"loading.connecting": "Connecting to server",
+		"loading.map":        "Loading map",
+		"loading.state":      "Waiting for game state",
+
+		"toast.no_target":    "No target",
+		"toast.out_of_reach": "Enemy out of reach",
+		"toast.occupied":     "Tile is occupied",
+		"toast.too_far":      "You can only move to an adjacent tile",
+		"toast.blocked":      "Can't go there",
+		"toast.map_changed":  "The map has changed",
+		"toast.no_overwatch": "Only a spear can hold overwatch",
+		"toast.reaction":     "Overwatch strike!",
+
+		"tile.grass":   "grass",
+		"tile.water":   "water",
+		"tile.stone":   "stone",
+		"tile.unknown": "unknown (%d)",
+
+		"debug.stats":        "FPS: %.1f | Players: %d | X: %.0f Y: %.0f | Weapon: %s",
+		"debug.updated":      " | Last update: %d ms ago",
+		"debug.uncapped":     "Uncapped frame rate (F2): TPS %.0f",
+		"debug.vsync":        "VSync, TPS %d (F2 – uncap)",
+		"debug.server_rules": "Server: damage %d, range %d",
+		"debug.client_range": " (client thinks %d!)",
+		"debug.no_rules":     "Server did not send weapon damage and range",
+		"debug.off_map":      "off the map",
+		"debug.cursor":       "Cursor: X: %.0f Y: %.0f | Tile: %d, %d | Type: %s",
+		"debug.frame_worst":  "Frame: worst %.1f ms",
+	},
+}
+
+// tr возвращает строку интерфейса на текущем языке; если перевода нет – русскую, иначе сам ключ
+func tr(key string) string {
+	if s, ok := translations[uiLang][key]; ok {
+		return s
+	}
+	if s, ok := translations["ru"][key]; ok {
+		return s
+	}
+	return key
 }
 
 // ==================== ВСПОМОГАТЕЛЬНЫЕ ФУНКЦИИ ====================
@@ -254,6 +891,34 @@ func createGlowImage(size int) *ebiten.Image {
 	return img
 }
 
+// createVignetteImage создаёт красную радиальную виньетку: прозрачный центр и плотные края
+func createVignetteImage(w, h int) *ebiten.Image {
+	pix := make([]byte, w*h*4)
+	cx, cy := float64(w)/2, float64(h)/2
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx := (float64(x) - cx) / cx
+			dy := (float64(y) - cy) / cy
+			d := math.Sqrt(dx*dx+dy*dy) / math.Sqrt2
+			t := (d - 0.45) / 0.55
+			if t < 0 {
+				t = 0
+			}
+			if t > 1 {
+				t = 1
+			}
+			a := t * t * 200
+			i := (y*w + x) * 4
+			// пиксели в premultiplied alpha
+			pix[i] = uint8(a)
+			pix[i+3] = uint8(a)
+		}
+	}
+	img := ebiten.NewImage(w, h)
+	img.WritePixels(pix)
+	return img
+}
+
 // createPlayerImage создаёт квадрат заданного цвета для отображения игрока
 func createPlayerImage(col NetColor) *ebiten.Image {
 	img := ebiten.NewImage(tileSize, tileSize)
@@ -369,6 +1034,120 @@ func generateMainMenuMap() [][]int {
 	return gameMap
 }
 
+// weaponCanHit повторяет серверную форму атаки: меч – соседние по стороне клетки,
+// копьё – по прямой на 1–2 клетки без диагоналей
+func weaponCanHit(weapon string, dx, dy int) bool {
+	adx, ady := dx, dy
+	if adx < 0 {
+		adx = -adx
+	}
+	if ady < 0 {
+		ady = -ady
+	}
+	if adx != 0 && ady != 0 {
+		return false
+	}
+	dist := adx + ady
+	switch weapon {
+	case "sword":
+		return dist == 1
+	case "spear":
+		return dist >= 1 && dist <= 2
+	}
+	return false
+}
+
+// attackTiles – смещения всех клеток, по которым можно ударить из (x, y):
+// перебирает квадрат в пределах дальности оружия и оставляет то, что пропускает canAttack
+func (g *Game) attackTiles(x, y int) [][2]int {
+	r := clientWeaponRange(g.myWeapon())
+	if r < 1 {
+		r = 1
+	}
+	var tiles [][2]int
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if (dx != 0 || dy != 0) && g.canAttack(x, y, dx, dy) {
+				tiles = append(tiles, [2]int{dx, dy})
+			}
+		}
+	}
+	return tiles
+}
+
+// clientWeaponRange – дальность оружия, как её понимает клиент по weaponCanHit
+func clientWeaponRange(weapon string) int {
+	r := 0
+	for d := 1; d <= 3; d++ {
+		if weaponCanHit(weapon, d, 0) {
+			r = d
+		}
+	}
+	return r
+}
+
+// myWeapon – моё оружие из состояния сервера; до первого состояния – выбранное в меню.
+// Вызывается под g.mu
+func (g *Game) myWeapon() string {
+	if g.myPlayer != nil && g.myPlayer.Weapon != "" {
+		return g.myPlayer.Weapon
+	}
+	return g.charWeapon
+}
+
+// diagonalReach – достаёт ли моё оружие до диагонального соседа (dx, dy) по правилам сервера
+func (g *Game) diagonalReach(dx, dy int) bool {
+	return g.diagonalMelee && g.myWeapon() == "sword" &&
+		(dx == 1 || dx == -1) && (dy == 1 || dy == -1)
+}
+
+// canAttack – можно ли ударить из клетки (x, y) по клетке со смещением (dx, dy):
+// форма оружия плюс правило угла – диагональный удар не проходит,
+// если обе клетки между бойцами непроходимы (как cornerBlocked на сервере)
+func (g *Game) canAttack(x, y, dx, dy int) bool {
+	if dx == 0 || dy == 0 {
+		return weaponCanHit(g.myWeapon(), dx, dy)
+	}
+	if !g.diagonalReach(dx, dy) {
+		return false
+	}
+	return !g.blockCorners || g.isFreeTile(x+dx, y) || g.isFreeTile(x, y+dy)
+}
+
+// isFreeTile – клетка внутри карты и проходима
+func (g *Game) isFreeTile(x, y int) bool {
+	return g.gameMap != nil && y >= 0 && y < len(g.gameMap) && x >= 0 && x < len(g.gameMap[y]) && g.gameMap[y][x] == 0
+}
+
+// isInView – попадает ли точка относительно камеры (с запасом cullMargin) в видимую
+// область размером w×h пикселей мира: при обзоре она больше экрана
+func isInView(sx, sy, w, h float64) bool {
+	return sx >= -cullMargin && sx <= w+cullMargin && sy >= -cullMargin && sy <= h+cullMargin
+}
+
+// tileTypeName возвращает название типа тайла для отладочного вывода
+func tileTypeName(t int) string {
+	switch t {
+	case 0:
+		return tr("tile.grass")
+	case 1:
+		return tr("tile.water")
+	case 2:
+		return tr("tile.stone")
+	}
+	return fmt.Sprintf(tr("tile.unknown"), t)
+}
+
+// lerpHP приближает отображаемое здоровье к реальному на долю hpLerpFactor за кадр
+func lerpHP(display float64, hp int) float64 {
+	target := float64(hp)
+	display += (target - display) * hpLerpFactor
+	if math.Abs(display-target) < 0.01 {
+		display = target
+	}
+	return display
+}
+
 // splitLongWord разбивает длинное слово на части, помещающиеся в maxWidth
 func splitLongWord(face font.Face, word string, maxWidth int) []string {
 	var parts []string
@@ -499,6 +1278,119 @@ func (g *Game) updateMusicVolume() {
 	if g.gameMusic != nil {
 		g.gameMusic.SetVolume(vol)
 	}
+	if g.heartbeat != nil {
+		g.heartbeat.SetVolume(vol * 0.5)
+	}
+	if g.turnChime != nil {
+		g.turnChime.SetVolume(vol)
+	}
+}
+
+// updateHeartbeat включает сердцебиение при низком здоровье и выключает в остальное время
+func (g *Game) updateHeartbeat() {
+	if g.heartbeat == nil {
+		return
+	}
+	low := false
+	if g.state == "game" && !g.showDeathScreen {
+		g.mu.RLock()
+		if g.myPlayer != nil && g.myPlayer.HP > 0 && g.myPlayer.HP <= lowHPThreshold {
+			low = true
+		}
+		g.mu.RUnlock()
+	}
+	if low && !g.heartbeat.IsPlaying() {
+		g.heartbeat.Rewind()
+		g.heartbeat.Play()
+	} else if !low && g.heartbeat.IsPlaying() {
+		g.heartbeat.Pause()
+	}
+}
+
+// updateTurnAlert один раз за ход, когда ход переходит ко мне, играет сигнал и, если
+// окно не в фокусе, мигает его заголовком, пока игрок не вернётся или ход не кончится
+func (g *Game) updateTurnAlert() {
+	g.mu.Lock()
+	pending := g.turnAlertPending
+	g.turnAlertPending = false
+	myTurn := g.myTurn
+	g.mu.Unlock()
+
+	if pending {
+		if g.turnChime != nil {
+			g.turnChime.Rewind()
+			g.turnChime.Play()
+		}
+		if !ebiten.IsFocused() {
+			g.titleBlinking = true
+		}
+	}
+	if !g.titleBlinking {
+		return
+	}
+
+	if ebiten.IsFocused() || !myTurn || g.state != "game" {
+		g.titleBlinking = false
+		g.titleBlinkOn = false
+		ebiten.SetWindowTitle(windowTitle)
+		return
+	}
+	if time.Since(g.titleBlinkAt) >= titleBlinkEvery {
+		g.titleBlinkOn = !g.titleBlinkOn
+		g.titleBlinkAt = time.Now()
+		if g.titleBlinkOn {
+			ebiten.SetWindowTitle("▶ " + tr("turn.yours") + " – " + windowTitle)
+		} else {
+			ebiten.SetWindowTitle(windowTitle)
+		}
+	}
+}
+
+// newChime синтезирует короткий двухнотный сигнал (16 бит, стерео), чтобы не зависеть
+// от звуковых файлов рядом с клиентом
+func newChime(ctx *audio.Context) *audio.Player {
+	const noteSeconds = 0.12
+	notes := []float64{880, 1320}
+	n := int(noteSeconds * sampleRate)
+	buf := make([]byte, 0, len(notes)*n*4)
+	for _, freq := range notes {
+		for i := 0; i < n; i++ {
+			t := float64(i) / sampleRate
+			env := math.Exp(-t * 25)
+			v := int16(math.Sin(2*math.Pi*freq*t) * env * 0.4 * math.MaxInt16)
+			lo, hi := byte(v), byte(uint16(v)>>8)
+			buf = append(buf, lo, hi, lo, hi)
+		}
+	}
+	return ctx.NewPlayerFromBytes(buf)
+}
+
+// newHeartbeat синтезирует зацикленное сердцебиение: два глухих удара («тук-тук»)
+// и пауза, около 70 ударов в минуту
+func newHeartbeat(ctx *audio.Context) *audio.Player {
+	const periodSeconds = 0.85
+	beats := []struct{ at, freq, gain float64 }{{0, 55, 0.6}, {0.22, 48, 0.45}}
+	n := int(periodSeconds * sampleRate)
+	buf := make([]byte, n*4)
+	for i := 0; i < n; i++ {
+		t := float64(i) / sampleRate
+		v := 0.0
+		for _, b := range beats {
+			if dt := t - b.at; dt >= 0 {
+				v += math.Sin(2*math.Pi*b.freq*dt) * math.Exp(-dt*18) * b.gain
+			}
+		}
+		s := int16(v * math.MaxInt16)
+		lo, hi := byte(s), byte(uint16(s)>>8)
+		buf[i*4], buf[i*4+1], buf[i*4+2], buf[i*4+3] = lo, hi, lo, hi
+	}
+	loop := audio.NewInfiniteLoop(bytes.NewReader(buf), int64(len(buf)))
+	player, err := ctx.NewPlayer(loop)
+	if err != nil {
+		log.Println("Не удалось создать сердцебиение:", err)
+		return nil
+	}
+	return player
 }
 
 // readLoop – горутина чтения сообщений от сервера
@@ -507,15 +1399,12 @@ func (g *Game) readLoop() {
 		if r := recover(); r != nil {
 			log.Println("Паника в readLoop:", r)
 		}
+		// игровой экран остаётся открытым: на нём игрок сам выбирает,
+		// переподключиться или вернуться в меню (handleLostScreen)
 		g.mu.Lock()
 		g.connected = false
 		g.connectionLost = true
-		g.disconnectTime = time.Now()
-		if g.state == "game" {
-			g.state = "mainmenu"
-			g.charError = "Потеряно соединение с сервером"
-			g.charConnecting = false
-		}
+		g.charConnecting = false
 		g.mu.Unlock()
 	}()
 
@@ -560,9 +1449,24 @@ func (g *Game) readLoop() {
 			case "map":
 				g.handleMap(msg)
 			case "state":
-				g.handleState(msg)
+				// применяется в Update: из нескольких состояний за кадр нужно только последнее
+				g.mu.Lock()
+				g.pendingState = msg
+				g.mu.Unlock()
 			case "chat":
 				g.handleChatMessage(msg)
+			case "game_over":
+				g.handleGameOver(msg)
+			case "hit":
+				g.handleHit(msg)
+			case "announce":
+				text, _ := msg["text"].(string)
+				g.mu.Lock()
+				g.announceText = text
+				g.announceAt = time.Now()
+				g.mu.Unlock()
+			case "new_match":
+				g.handleNewMatch()
 			}
 		}
 	}
@@ -578,6 +1482,9 @@ func (g *Game) handleInit(msg map[string]interface{}) {
 		g.ready = true
 		g.state = "game"
 		g.connected = true
+		if g.connPhase < phaseAwaitMap {
+			g.connPhase = phaseAwaitMap
+		}
 		g.showDeathScreen = false
 
 		startX, startY := 0.0, 0.0
@@ -631,6 +1538,7 @@ func (g *Game) handleInit(msg map[string]interface{}) {
 			Image:       img,
 			Initialized: true,
 			HP:          10,
+			DisplayHP:   10,
 			Color:       playerColor,
 			IsMe:        true,
 			LastUpdate:  time.Now(),
@@ -638,18 +1546,53 @@ func (g *Game) handleInit(msg map[string]interface{}) {
 		}
 
 		g.players[id] = player
-		g.myPlayer = player
+		g.adoptSelf(id)
+
+		g.diagonalMelee, g.blockCorners = false, false
+		if rules, ok := msg["rules"].(map[string]interface{}); ok {
+			g.diagonalMelee, _ = rules["diagonal_melee"].(bool)
+			g.blockCorners, _ = rules["block_corners"].(bool)
+		}
+		g.weaponDamage, g.weaponRange = 0, 0
+		if w, ok := msg["weapon"].(map[string]interface{}); ok {
+			damage, _ := w["damage"].(float64)
+			reach, _ := w["range"].(float64)
+			g.weaponDamage, g.weaponRange = int(damage), int(reach)
+		}
 
 		log.Printf("Инициализирован с ID: %s, позиция: %.0f,%.0f, раса: %s, оружие: %s", id, startX, startY, race, g.charWeapon)
 	}
 }
 
+// adoptSelf делает запись id единственным своим игроком: ставит ей IsMe, убирает
+// оставшиеся от прошлого подключения свои записи и направляет на неё g.myPlayer.
+// Нужна, потому что init и первое состояние могут прийти в любом порядке.
+// Вызывать под g.mu
+func (g *Game) adoptSelf(id string) {
+	for pid, pl := range g.players {
+		if pid == id {
+			pl.IsMe = true
+		} else if pl.IsMe {
+			delete(g.players, pid)
+		}
+	}
+	g.myPlayer = g.players[id]
+}
+
 // handleMap обрабатывает сообщение "map" от сервера
 func (g *Game) handleMap(msg map[string]interface{}) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	if data, ok := msg["data"].([]interface{}); ok {
+		// Карта сменилась посреди игры: сервер уже переставил игроков на новые клетки,
+		// а предсказанный ход считался по старой карте и больше не нужен
+		if changed, _ := msg["changed"].(bool); changed && g.gameMap != nil {
+			g.predicting = false
+			g.toastText = tr("toast.map_changed")
+			g.toastTime = time.Now()
+		}
+
 		g.gameMap = make([][]int, len(data))
 		for i, row := range data {
 			if rowSlice, ok := row.([]interface{}); ok {
@@ -661,25 +1604,78 @@ func (g *Game) handleMap(msg map[string]interface{}) {
 				}
 			}
 		}
+		if g.connPhase < phaseAwaitState {
+			g.connPhase = phaseAwaitState
+		}
+
+		// Безопасная зона: сервер присылает границы в клетках (включительно);
+		// старый сервер их не шлёт – тогда считаем центр 5x5, как в генерации карты
+		cx := len(g.gameMap[0]) / 2
+		g.spawnZone = image.Rect(cx-2, cx-2, cx+3, cx+3)
+		if spawn, ok := msg["spawn"].(map[string]interface{}); ok {
+			minV, okMin := spawn["min"].(float64)
+			maxV, okMax := spawn["max"].(float64)
+			if okMin && okMax {
+				g.spawnZone = image.Rect(int(minV), int(minV), int(maxV)+1, int(maxV)+1)
+			}
+		}
 		log.Printf("Карта получена: %dx%d", len(g.gameMap[0]), len(g.gameMap))
 	}
 }
 
+// applyPendingState применяет последнее пришедшее с прошлого кадра состояние;
+// более ранние за это время уже устарели и пропускаются
+func (g *Game) applyPendingState() {
+	g.mu.Lock()
+	msg := g.pendingState
+	g.pendingState = nil
+	g.mu.Unlock()
+	if msg != nil {
+		g.handleState(msg)
+	}
+}
+
 // handleState обрабатывает сообщение "state" от сервера (список игроков)
 func (g *Game) handleState(msg map[string]interface{}) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	g.lastStateTime = time.Now()
+	// состояние может прийти раньше init/карты – тогда игра ещё не готова
+	if g.ready && g.gameMap != nil {
+		g.connPhase = phasePlaying
+	}
+
+	turnStartedAt, _ := msg["turn_started_at"].(float64)
 	if currentTurn, ok := msg["current_turn"].(string); ok {
+		// новый ход – даже если он снова мой (соперник вышел), действие можно отправить заново
+		if currentTurn != g.currentTurn || int64(turnStartedAt) != g.turnStartedAt {
+			g.actedThisTurn = false
+		}
+		g.turnStartedAt = int64(turnStartedAt)
 		g.currentTurn = currentTurn
+		wasMyTurn := g.myTurn
 		g.myTurn = (g.currentTurn == g.id)
+		if g.myTurn && !wasMyTurn {
+			g.turnAlertPending = true
+		}
+	}
+	if order, ok := msg["turn_order"].([]interface{}); ok {
+		g.turnOrder = g.turnOrder[:0]
+		for _, v := range order {
+			if id, ok := v.(string); ok {
+				g.turnOrder = append(g.turnOrder, id)
+			}
+		}
 	}
 	if timeLeft, ok := msg["turn_time_left"].(float64); ok {
 		if timeLeft < 0 {
 			timeLeft = 0
 		}
 		g.turnTimeLeft = timeLeft
+		g.turnTimeSyncedAt = time.Now()
 	}
+	g.waitingForPlayers, _ = msg["waiting_for_players"].(bool)
 
 	if data, ok := msg["data"].([]interface{}); ok {
 		ts := time.Now()
@@ -697,6 +1693,10 @@ func (g *Game) handleState(msg map[string]interface{}) {
 				tx, _ := playerMap["tx"].(float64)
 				ty, _ := playerMap["ty"].(float64)
 				hp, _ := playerMap["hp"].(float64)
+				poison, _ := playerMap["poison"].(float64)
+				defending, _ := playerMap["defending"].(bool)
+				overwatch, _ := playerMap["overwatch"].(bool)
+				reconnecting, _ := playerMap["reconnecting"].(bool)
 
 				pl, exists := g.players[id]
 
@@ -739,6 +1739,11 @@ func (g *Game) handleState(msg map[string]interface{}) {
 						TargetY:     ty,
 						Initialized: true,
 						HP:          int(hp),
+						Poison:      int(poison),
+						Defending:   defending,
+						Overwatch:   overwatch,
+						Reconnect:   reconnecting,
+						DisplayHP:   hp,
 						Color:       col,
 						IsMe:        id == g.id,
 						LastUpdate:  ts,
@@ -746,10 +1751,6 @@ func (g *Game) handleState(msg map[string]interface{}) {
 					}
 
 					g.players[id] = pl
-
-					if id == g.id {
-						g.myPlayer = pl
-					}
 				} else {
 					if pl.Color.R != col.R || pl.Color.G != col.G || pl.Color.B != col.B || pl.Color.A != col.A {
 						pl.Image = createPlayerImage(col)
@@ -758,22 +1759,30 @@ func (g *Game) handleState(msg map[string]interface{}) {
 					pl.Race = race
 					pl.Weapon = weapon
 
-					if math.Abs(pl.X-tx) > 0.1 || math.Abs(pl.Y-ty) > 0.1 {
+					// Анимация хода перезапускается только при новой цели: повтор той же цели
+					// в следующих состояниях не должен сбрасывать уже идущее движение
+					offTarget := math.Abs(pl.X-tx) > 0.1 || math.Abs(pl.Y-ty) > 0.1
+					if id == g.id {
+						g.reconcileMyPosition(pl, tx, ty)
+					} else if tx != pl.TargetX || ty != pl.TargetY || (!pl.Moving && offTarget) {
 						pl.Moving = true
 						pl.MoveStartTime = time.Now()
 						pl.MoveStartX = pl.X
 						pl.MoveStartY = pl.Y
 						pl.MoveEndX = tx
 						pl.MoveEndY = ty
+						pl.MoveSeconds = moveDuration
 						pl.TargetX = tx
 						pl.TargetY = ty
-					} else {
+					} else if !offTarget {
 						pl.Moving = false
-						pl.TargetX = tx
-						pl.TargetY = ty
 					}
 
 					pl.HP = int(hp)
+					pl.Poison = int(poison)
+					pl.Defending = defending
+					pl.Overwatch = overwatch
+					pl.Reconnect = reconnecting
 					pl.Name = name
 					pl.LastUpdate = ts
 				}
@@ -782,24 +1791,148 @@ func (g *Game) handleState(msg map[string]interface{}) {
 			}
 		}
 
-		if _, ok := seen[g.id]; !ok && g.myPlayer != nil {
+		if seen[g.id] {
+			// в том числе после перезапуска матча, когда погибший игрок снова появляется в состоянии
+			g.adoptSelf(g.id)
+		} else if g.myPlayer != nil {
 			g.showDeathScreen = true
 			g.myPlayer = nil
 		}
 
+		// игроки вне области видимости (-view-radius) живы: просто убираем их без растворения
+		hidden := make(map[string]bool)
+		if list, ok := msg["hidden"].([]interface{}); ok {
+			for _, v := range list {
+				if id, ok := v.(string); ok {
+					hidden[id] = true
+				}
+			}
+		}
+
 		for id := range g.players {
+			if hidden[id] && id != g.id {
+				delete(g.players, id)
+				continue
+			}
 			if !seen[id] && id != g.id {
+				// даём полоске здоровья доиграть анимацию до нуля, пока игрок растворяется
+				pl := g.players[id]
+				pl.HP = 0
+				pl.FadeStart = ts
+				g.fadingPlayers[id] = pl
 				delete(g.players, id)
 			}
 		}
 	}
 }
 
+// handleGameOver обрабатывает сообщение "game_over" и открывает экран итогов
+func (g *Game) handleGameOver(msg map[string]interface{}) {
+	summary := MatchSummary{}
+	summary.Winner, _ = msg["winner"].(string)
+	summary.WinnerID, _ = msg["winner_id"].(string)
+	summary.Duration, _ = msg["duration"].(float64)
+	if rows, ok := msg["scoreboard"].([]interface{}); ok {
+		for _, row := range rows {
+			if rowMap, ok := row.(map[string]interface{}); ok {
+				name, _ := rowMap["name"].(string)
+				kills, _ := rowMap["kills"].(float64)
+				deaths, _ := rowMap["deaths"].(float64)
+				summary.Scoreboard = append(summary.Scoreboard, ScoreEntry{Name: name, Kills: int(kills), Deaths: int(deaths)})
+			}
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.summary = summary
+	g.state = "summary"
+	g.showDeathScreen = false
+	g.chatOpen = false
+	log.Printf("Матч окончен, победитель: %s", summary.Winner)
+}
+
+// reconcileMyPosition сверяет позицию своего игрока от сервера с предсказанной:
+// пока ход не подтверждён, устаревшие состояния не откатывают игрока назад,
+// небольшое расхождение сглаживается короткой коррекцией, а большое применяется сразу
+func (g *Game) reconcileMyPosition(pl *Player, tx, ty float64) {
+	if g.predicting {
+		confirmed := math.Abs(tx-g.predictedX) < 0.5 && math.Abs(ty-g.predictedY) < 0.5
+		if !confirmed && time.Since(g.predictedAt).Seconds() < predictionTimeout {
+			return
+		}
+		g.predicting = false
+	}
+	if math.Abs(pl.TargetX-tx) < 0.1 && math.Abs(pl.TargetY-ty) < 0.1 {
+		return
+	}
+
+	pl.TargetX = tx
+	pl.TargetY = ty
+	if math.Hypot(tx-pl.X, ty-pl.Y) > correctionSnapDistance {
+		pl.X = tx
+		pl.Y = ty
+		pl.Moving = false
+		return
+	}
+	pl.Moving = true
+	pl.MoveStartTime = time.Now()
+	pl.MoveStartX = pl.X
+	pl.MoveStartY = pl.Y
+	pl.MoveEndX = tx
+	pl.MoveEndY = ty
+	pl.MoveSeconds = correctionDuration
+}
+
+// handleNewMatch возвращает игрока с экрана итогов, смерти или наблюдения в новый матч
+func (g *Game) handleNewMatch() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.state == "summary" {
+		g.state = "game"
+	}
+	g.showDeathScreen = false
+	g.spectating = false
+	g.spectatorFollowID = ""
+	g.spectatorFreeCam = false
+	g.spectatorOverview = false
+	g.zoomAroundCenter(1)
+	g.pendingAction = nil
+	g.damageIndicators = nil
+	log.Println("Начался новый матч")
+}
+
+// handleHit обрабатывает событие попадания: если били по мне, запоминает направление на атакующего
+func (g *Game) handleHit(msg map[string]interface{}) {
+	target, _ := msg["target"].(string)
+	ax, okX := msg["ax"].(float64)
+	ay, okY := msg["ay"].(float64)
+	if !okX || !okY {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if target != g.id || g.myPlayer == nil {
+		return
+	}
+	if reaction, _ := msg["reaction"].(bool); reaction {
+		g.toastText = tr("toast.reaction")
+		g.toastTime = time.Now()
+	}
+	me := g.myPlayer
+	g.damageIndicators = append(g.damageIndicators, DamageIndicator{
+		Angle: math.Atan2(ay-me.TargetY, ax-me.TargetX),
+		Start: time.Now(),
+	})
+}
+
 // handleChatMessage обрабатывает входящее сообщение чата
 func (g *Game) handleChatMessage(msg map[string]interface{}) {
 	from, _ := msg["from"].(string)
 	text, _ := msg["text"].(string)
 	msgTime, _ := msg["time"].(float64)
+	spectator, _ := msg["spectator"].(bool)
 
 	var msgColor NetColor
 	if colorData, ok := msg["color"].(map[string]interface{}); ok {
@@ -822,6 +1955,8 @@ func (g *Game) handleChatMessage(msg map[string]interface{}) {
 		Text:  text,
 		Time:  int64(msgTime),
 		Color: msgColor,
+
+		Spectator: spectator,
 	}
 
 	g.mu.Lock()
@@ -839,12 +1974,16 @@ func (g *Game) handleChatMessage(msg map[string]interface{}) {
 
 // Update вызывается каждый кадр
 func (g *Game) Update() error {
+	g.recordFrameTime()
+	g.applyPendingState()
+	g.updateCursor()
+
 	if ebiten.IsKeyPressed(ebiten.KeyF11) {
 		now := time.Now()
 		if now.Sub(g.lastF11Press) > 200*time.Millisecond {
-			g.fullscreen = !g.fullscreen
-			ebiten.SetFullscreen(g.fullscreen)
+			g.setFullscreen(!g.fullscreen)
 			g.lastF11Press = now
+			g.saveSettings()
 		}
 	}
 
@@ -878,6 +2017,8 @@ func (g *Game) Update() error {
 		g.updateGame()
 	case "settings":
 		g.updateSettings()
+	case "summary":
+		g.updateSummary()
 	}
 
 	// Переключение музыки в зависимости от состояния
@@ -905,6 +2046,8 @@ func (g *Game) Update() error {
 			g.currentMusic = "none"
 		}
 	}
+	g.updateHeartbeat()
+	g.updateTurnAlert()
 
 	g.prevEscPressed = escPressed
 	return nil
@@ -917,38 +2060,280 @@ func (g *Game) handleDeathScreen() {
 	dy := (screenH - dh) / 2
 	g.deathScreenRects.bg = image.Rect(dx, dy, dx+dw, dy+dh)
 
-	btnW, btnH := 150, 50
-	btnX := dx + (dw-btnW)/2
+	btnW, btnH := 170, 50
+	spacing := 20
+	btnX := dx + (dw-2*btnW-spacing)/2
 	btnY := dy + 130
 	g.deathScreenRects.ok = image.Rect(btnX, btnY, btnX+btnW, btnY+btnH)
+	g.deathScreenRects.spectate = image.Rect(btnX+btnW+spacing, btnY, btnX+2*btnW+spacing, btnY+btnH)
 
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
 		pt := image.Pt(x, y)
 		if pt.In(g.deathScreenRects.ok) {
 			g.disconnect()
+			clearSession()
 			g.state = "mainmenu"
 			g.showDeathScreen = false
 		}
+		if pt.In(g.deathScreenRects.spectate) {
+			g.startSpectating()
+		}
 	}
 }
 
-// handleQuitConfirm обрабатывает диалог подтверждения выхода
-func (g *Game) handleQuitConfirm() {
-	dw, dh := 700, 300
-	dx := (screenW - dw) / 2
-	dy := (screenH - dh) / 2
-	g.quitConfirmRects.bg = image.Rect(dx, dy, dx+dw, dy+dh)
+// handleLostScreen обрабатывает экран потери соединения: «Переподключиться» (Enter)
+// сразу входит тем же персонажем, «В меню» (Esc) закрывает игру
+func (g *Game) handleLostScreen() {
+	btnW, btnH := 300, 50
+	spacing := 20
+	btnX := (screenW - 2*btnW - spacing) / 2
+	btnY := screenH/2 + 80
+	g.lostScreenRects.reconnect = image.Rect(btnX, btnY, btnX+btnW, btnY+btnH)
+	g.lostScreenRects.menu = image.Rect(btnX+btnW+spacing, btnY, btnX+2*btnW+spacing, btnY+btnH)
+
+	reconnect := ebiten.IsKeyPressed(ebiten.KeyEnter)
+	toMenu := ebiten.IsKeyPressed(ebiten.KeyEscape) && !g.prevEscPressed
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		pt := image.Pt(ebiten.CursorPosition())
+		reconnect = reconnect || pt.In(g.lostScreenRects.reconnect)
+		toMenu = toMenu || pt.In(g.lostScreenRects.menu)
+	}
 
-	btnW, btnH := 220, 50
-	spacing := 15
-	totalBtnWidth := 3*btnW + 2*spacing
-	startX := dx + (dw-totalBtnWidth)/2
-	btnY := dy + 180
+	switch {
+	case reconnect:
+		g.disconnect()
+		g.connect()
+		if g.charError != "" {
+			// сервер недоступен – ошибку покажет экран персонажа, откуда можно повторить
+			g.state = "character"
+		}
+		// нажатие кнопки не должно сразу стать кликом по полю
+		g.prevLeftMouse = true
+	case toMenu:
+		g.disconnect()
+		g.mu.Lock()
+		g.connectionLost = false
+		g.mu.Unlock()
+		g.state = "mainmenu"
+	}
+}
 
-	g.quitConfirmRects.yes = image.Rect(startX, btnY, startX+btnW, btnY+btnH)
-	g.quitConfirmRects.no = image.Rect(startX+btnW+spacing, btnY, startX+2*btnW+spacing, btnY+btnH)
-	g.quitConfirmRects.exit = image.Rect(startX+2*btnW+2*spacing, btnY, startX+3*btnW+2*spacing, btnY+btnH)
+// startSpectating закрывает экран смерти и переводит клиент в режим наблюдателя
+func (g *Game) startSpectating() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.showDeathScreen = false
+	g.spectating = true
+	g.spectatorFollowID = ""
+	g.spectatorFreeCam = false
+	g.spectatorOverview = false
+	g.pendingAction = nil
+	// собственный квадрат больше не приходит в состоянии – убираем его с поля
+	delete(g.players, g.id)
+	// клик по кнопке не должен сразу выбрать игрока для слежения
+	g.prevLeftMouse = true
+}
+
+// spectatorTargets возвращает ID живых игроков, отсортированные по имени (вызывать под g.mu)
+func (g *Game) spectatorTargets() []string {
+	ids := make([]string, 0, len(g.players))
+	for id, pl := range g.players {
+		if id != g.id && pl.Initialized {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return g.players[ids[i]].Name < g.players[ids[j]].Name
+	})
+	return ids
+}
+
+// spectatedID возвращает ID игрока, за которым сейчас следит камера наблюдателя (вызывать под g.mu)
+func (g *Game) spectatedID() string {
+	if !g.spectating || g.spectatorFreeCam || g.spectatorOverview {
+		return ""
+	}
+	if _, ok := g.players[g.spectatorFollowID]; ok {
+		return g.spectatorFollowID
+	}
+	return g.currentTurn
+}
+
+// updateSpectator обрабатывает камеру наблюдателя: ←/→ переключают игрока,
+// клик по игроку начинает следить за ним, F включает свободную камеру (WASD),
+// Z – обзор, в котором видны все живые игроки
+func (g *Game) updateSpectator(clicked bool) {
+	leftKey := ebiten.IsKeyPressed(ebiten.KeyArrowLeft)
+	rightKey := ebiten.IsKeyPressed(ebiten.KeyArrowRight)
+	fKey := ebiten.IsKeyPressed(ebiten.KeyF)
+	zKey := ebiten.IsKeyPressed(ebiten.KeyZ)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.players[g.spectatorFollowID]; !ok {
+		g.spectatorFollowID = ""
+	}
+
+	if ids := g.spectatorTargets(); len(ids) > 0 && ((leftKey && !g.prevArrowLeft) || (rightKey && !g.prevArrowRight)) {
+		current := g.spectatedID()
+		idx := -1
+		for i, id := range ids {
+			if id == current {
+				idx = i
+				break
+			}
+		}
+		switch {
+		case idx == -1 && rightKey:
+			idx = 0
+		case idx == -1:
+			idx = len(ids) - 1
+		case rightKey:
+			idx = (idx + 1) % len(ids)
+		default:
+			idx = (idx - 1 + len(ids)) % len(ids)
+		}
+		g.spectatorFollowID = ids[idx]
+		g.spectatorFreeCam = false
+		g.spectatorOverview = false
+	}
+
+	if clicked {
+		mx, my := ebiten.CursorPosition()
+		tileX := int((float64(mx)/g.camZoom + g.camX) / tileSize)
+		tileY := int((float64(my)/g.camZoom + g.camY) / tileSize)
+		for id, pl := range g.players {
+			if id != g.id && int(pl.X/tileSize) == tileX && int(pl.Y/tileSize) == tileY {
+				g.spectatorFollowID = id
+				g.spectatorFreeCam = false
+				g.spectatorOverview = false
+				break
+			}
+		}
+	}
+
+	if fKey && !g.prevFKey {
+		g.spectatorFreeCam = !g.spectatorFreeCam
+		g.spectatorOverview = false
+	}
+	if zKey && !g.prevZKey {
+		g.spectatorOverview = !g.spectatorOverview
+		g.spectatorFreeCam = false
+	}
+	g.prevArrowLeft = leftKey
+	g.prevArrowRight = rightKey
+	g.prevFKey = fKey
+	g.prevZKey = zKey
+
+	if g.spectatorOverview {
+		g.updateOverviewCamera()
+		return
+	}
+	// вне обзора камера плавно возвращается к обычному масштабу
+	if g.camZoom != 1 {
+		zoom := g.camZoom + (1-g.camZoom)*0.15
+		if 1-zoom < 0.005 {
+			zoom = 1
+		}
+		g.zoomAroundCenter(zoom)
+	}
+
+	if g.spectatorFreeCam {
+		const panSpeed = 10.0
+		if ebiten.IsKeyPressed(ebiten.KeyW) {
+			g.camY -= panSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyS) {
+			g.camY += panSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyA) {
+			g.camX -= panSpeed
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyD) {
+			g.camX += panSpeed
+		}
+		return
+	}
+
+	if target, ok := g.players[g.spectatedID()]; ok {
+		g.camX += (target.TargetX - screenW/2 - g.camX) * 0.1
+		g.camY += (target.TargetY - screenH/2 - g.camY) * 0.1
+	}
+}
+
+// fitCameraToPlayers находит середину и масштаб кадра, в который помещаются все живые
+// игроки вместе с запасом overviewMargin. Масштаб не крупнее обычного и не мельче minCamZoom.
+// Вызывать под g.mu
+func (g *Game) fitCameraToPlayers() (centerX, centerY, zoom float64, ok bool) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for id, pl := range g.players {
+		if id == g.id || !pl.Initialized {
+			continue
+		}
+		minX, maxX = min(minX, pl.TargetX), max(maxX, pl.TargetX)
+		minY, maxY = min(minY, pl.TargetY), max(maxY, pl.TargetY)
+		ok = true
+	}
+	if !ok {
+		return 0, 0, 1, false
+	}
+	w := maxX - minX + 2*overviewMargin
+	h := maxY - minY + 2*overviewMargin
+	zoom = min(1, screenW/w, screenH/h)
+	return (minX + maxX) / 2, (minY + maxY) / 2, max(zoom, minCamZoom), true
+}
+
+// updateOverviewCamera плавно ведёт середину кадра и масштаб к обзору всех живых игроков.
+// Вызывать под g.mu
+func (g *Game) updateOverviewCamera() {
+	cx, cy, zoom, ok := g.fitCameraToPlayers()
+	if !ok {
+		return
+	}
+	g.zoomAroundCenter(g.camZoom + (zoom-g.camZoom)*0.1)
+	curX, curY := g.camX+screenW/(2*g.camZoom), g.camY+screenH/(2*g.camZoom)
+	curX += (cx - curX) * 0.1
+	curY += (cy - curY) * 0.1
+	g.camX, g.camY = curX-screenW/(2*g.camZoom), curY-screenH/(2*g.camZoom)
+}
+
+// zoomAroundCenter меняет масштаб камеры, не сдвигая середину кадра. Вызывать под g.mu
+func (g *Game) zoomAroundCenter(zoom float64) {
+	cx, cy := g.camX+screenW/(2*g.camZoom), g.camY+screenH/(2*g.camZoom)
+	g.camZoom = zoom
+	g.camX, g.camY = cx-screenW/(2*zoom), cy-screenH/(2*zoom)
+}
+
+// worldView возвращает залитую фоном область слоя мира размером w×h.
+// Слой создаётся один раз – под самый мелкий масштаб
+func (g *Game) worldView(w, h int) *ebiten.Image {
+	if g.worldLayer == nil {
+		g.worldLayer = ebiten.NewImage(int(math.Ceil(screenW/minCamZoom)), int(math.Ceil(screenH/minCamZoom)))
+	}
+	view := g.worldLayer.SubImage(image.Rect(0, 0, w, h)).(*ebiten.Image)
+	view.Fill(color.RGBA{20, 20, 40, 255})
+	return view
+}
+
+// handleQuitConfirm обрабатывает диалог подтверждения выхода
+func (g *Game) handleQuitConfirm() {
+	dw, dh := 700, 300
+	dx := (screenW - dw) / 2
+	dy := (screenH - dh) / 2
+	g.quitConfirmRects.bg = image.Rect(dx, dy, dx+dw, dy+dh)
+
+	btnW, btnH := 220, 50
+	spacing := 15
+	totalBtnWidth := 3*btnW + 2*spacing
+	startX := dx + (dw-totalBtnWidth)/2
+	btnY := dy + 180
+
+	g.quitConfirmRects.yes = image.Rect(startX, btnY, startX+btnW, btnY+btnH)
+	g.quitConfirmRects.no = image.Rect(startX+btnW+spacing, btnY, startX+2*btnW+spacing, btnY+btnH)
+	g.quitConfirmRects.exit = image.Rect(startX+2*btnW+2*spacing, btnY, startX+3*btnW+2*spacing, btnY+btnH)
 
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
@@ -956,6 +2341,7 @@ func (g *Game) handleQuitConfirm() {
 		if pt.In(g.quitConfirmRects.yes) {
 			if g.state == "game" {
 				g.disconnect()
+				clearSession()
 				g.state = "mainmenu"
 			} else {
 				os.Exit(0)
@@ -964,6 +2350,7 @@ func (g *Game) handleQuitConfirm() {
 		} else if pt.In(g.quitConfirmRects.no) {
 			g.showQuitConfirm = false
 		} else if pt.In(g.quitConfirmRects.exit) {
+			clearSession()
 			os.Exit(0)
 		}
 	}
@@ -973,10 +2360,59 @@ func (g *Game) handleQuitConfirm() {
 	}
 }
 
+// updateSummary обрабатывает кнопки экрана итогов матча
+func (g *Game) updateSummary() {
+	btnW, btnH := 300, 60
+	btnY := 850
+	g.summaryRects.again = image.Rect(screenW/2-btnW-20, btnY, screenW/2-20, btnY+btnH)
+	g.summaryRects.menu = image.Rect(screenW/2+20, btnY, screenW/2+20+btnW, btnY+btnH)
+
+	// за таблицей итогов камера показывает всех оставшихся на поле
+	g.mu.Lock()
+	g.updateOverviewCamera()
+	g.mu.Unlock()
+
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		x, y := ebiten.CursorPosition()
+		pt := image.Pt(x, y)
+		if pt.In(g.summaryRects.again) {
+			g.disconnect()
+			g.charSelectedColor = -1
+			g.colorsFetched = false
+			g.charError = ""
+			g.charConnecting = false
+			g.state = "character"
+		} else if pt.In(g.summaryRects.menu) {
+			g.disconnect()
+			g.state = "mainmenu"
+		}
+	}
+}
+
+// updateMenuDrift сдвигает фон главного меню. Направление медленно блуждает: угол –
+// сумма двух синусов разной частоты, поэтому меняется плавно и без видимого цикла.
+// Смещение сворачивается по размеру карты, чтобы не росло бесконечно
+func (g *Game) updateMenuDrift() {
+	g.mainMenuDriftTime += 1 / float64(ebiten.TPS())
+	t := 2 * math.Pi * g.mainMenuDriftTime / menuDriftPeriod
+	angle := math.Atan2(0.5, 1) + 1.2*math.Sin(t) + 0.5*math.Sin(2.7*t+1)
+
+	g.mainMenuOffsetX += math.Cos(angle) * menuDriftSpeed
+	g.mainMenuOffsetY += math.Sin(angle) * menuDriftSpeed
+
+	if g.mainMenuMap != nil {
+		w := float64(len(g.mainMenuMap[0]) * tileSize)
+		h := float64(len(g.mainMenuMap) * tileSize)
+		g.mainMenuOffsetX = math.Mod(g.mainMenuOffsetX+w, w)
+		g.mainMenuOffsetY = math.Mod(g.mainMenuOffsetY+h, h)
+	}
+}
+
 // updateMainMenu обновляет логику главного меню
 func (g *Game) updateMainMenu() {
-	g.mainMenuOffsetX += 1.0
-	g.mainMenuOffsetY += 0.5
+	if !g.perfMode {
+		g.updateMenuDrift()
+	}
 
 	btnW, btnH := 400, 80
 	startY := 400
@@ -986,11 +2422,42 @@ func (g *Game) updateMainMenu() {
 		g.mainMenuButtonRects[i] = image.Rect(x, y, x+btnW, y+btnH)
 	}
 
+	// Движение мыши над кнопкой переносит на неё выделение
+	cx, cy := ebiten.CursorPosition()
+	cursor := image.Pt(cx, cy)
+	if cursor != g.mainMenuLastCursor {
+		for i, rect := range g.mainMenuButtonRects {
+			if cursor.In(rect) {
+				g.mainMenuSelected = i
+				break
+			}
+		}
+		g.mainMenuLastCursor = cursor
+	}
+
+	// Стрелки вверх/вниз перемещают выделение по кругу, Enter нажимает кнопку
+	upPressed := ebiten.IsKeyPressed(ebiten.KeyUp)
+	downPressed := ebiten.IsKeyPressed(ebiten.KeyDown)
+	enterPressed := ebiten.IsKeyPressed(ebiten.KeyEnter)
+	n := len(g.mainMenuButtons)
+	if upPressed && !g.prevUpPressed {
+		g.mainMenuSelected = (g.mainMenuSelected - 1 + n) % n
+	}
+	if downPressed && !g.prevDownPressed {
+		g.mainMenuSelected = (g.mainMenuSelected + 1) % n
+	}
+	g.prevUpPressed = upPressed
+	g.prevDownPressed = downPressed
+	if enterPressed && !g.prevEnterPressed {
+		g.prevEnterPressed = enterPressed
+		g.mainMenuButtons[g.mainMenuSelected].Action(g)
+		return
+	}
+	g.prevEnterPressed = enterPressed
+
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		x, y := ebiten.CursorPosition()
-		pt := image.Pt(x, y)
 		for i, rect := range g.mainMenuButtonRects {
-			if pt.In(rect) {
+			if cursor.In(rect) {
 				g.mainMenuButtons[i].Action(g)
 				break
 			}
@@ -1007,8 +2474,20 @@ func (g *Game) updateSettings() {
 	btnX, btnY := 250, 350
 	btnW, btnH := 400, 40
 	g.fullscreenBtn = image.Rect(btnX, btnY, btnX+btnW, btnY+btnH)
-
-	backX, backY := screenW/2-100, 800
+	g.windowSizeBtn = image.Rect(btnX+btnW+20, btnY, btnX+2*btnW+20, btnY+btnH)
+	g.confirmActionsBtn = image.Rect(btnX, btnY+70, btnX+btnW+200, btnY+70+btnH)
+	g.performanceBtn = image.Rect(btnX+btnW+220, btnY+70, btnX+2*btnW+420, btnY+70+btnH)
+	g.spawnZoneBtn = image.Rect(btnX, btnY+140, btnX+btnW+200, btnY+140+btnH)
+	g.chatSizeBtn = image.Rect(btnX, btnY+210, btnX+btnW+200, btnY+210+btnH)
+	g.chatCornerBtn = image.Rect(btnX, btnY+280, btnX+btnW+200, btnY+280+btnH)
+	g.languageBtn = image.Rect(btnX, btnY+350, btnX+btnW+200, btnY+350+btnH)
+	g.uiScaleBtn = image.Rect(btnX, btnY+420, btnX+btnW+200, btnY+420+btnH)
+	g.leftHandedBtn = image.Rect(btnX, btnY+490, btnX+btnW+200, btnY+490+btnH)
+	g.coloredNamesBtn = image.Rect(btnX+btnW+220, btnY+490, btnX+2*btnW+420, btnY+490+btnH)
+	g.chatTimeBtn = image.Rect(btnX, btnY+560, btnX+btnW+200, btnY+560+btnH)
+	g.spectatorChatBtn = image.Rect(btnX+btnW+220, btnY+560, btnX+2*btnW+420, btnY+560+btnH)
+
+	backX, backY := screenW/2-100, 990
 	backW, backH := 200, 60
 	g.backBtn = image.Rect(backX, backY, backX+backW, backY+backH)
 
@@ -1023,13 +2502,119 @@ func (g *Game) updateSettings() {
 		if pt.In(g.fullscreenBtn) {
 			now := time.Now()
 			if now.Sub(g.lastFullscreenToggle) > 200*time.Millisecond {
-				g.fullscreen = !g.fullscreen
-				ebiten.SetFullscreen(g.fullscreen)
+				g.setFullscreen(!g.fullscreen)
 				g.lastFullscreenToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.windowSizeBtn) {
+			now := time.Now()
+			if now.Sub(g.lastWindowSizeToggle) > 200*time.Millisecond {
+				g.nextWindowSize()
+				g.lastWindowSizeToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.confirmActionsBtn) {
+			now := time.Now()
+			if now.Sub(g.lastConfirmToggle) > 200*time.Millisecond {
+				g.confirmActions = !g.confirmActions
+				g.lastConfirmToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.performanceBtn) {
+			now := time.Now()
+			if now.Sub(g.lastPerfToggle) > 200*time.Millisecond {
+				g.perfMode = !g.perfMode
+				g.lastPerfToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.spawnZoneBtn) {
+			now := time.Now()
+			if now.Sub(g.lastSpawnZoneToggle) > 200*time.Millisecond {
+				g.showSpawnZone = !g.showSpawnZone
+				g.lastSpawnZoneToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.chatSizeBtn) || pt.In(g.chatCornerBtn) {
+			now := time.Now()
+			if now.Sub(g.lastChatSettingClick) > 200*time.Millisecond {
+				if pt.In(g.chatSizeBtn) {
+					g.nextChatSize()
+				} else if g.chatCorner == "right" {
+					g.chatCorner = "left"
+				} else {
+					g.chatCorner = "right"
+				}
+				g.lastChatSettingClick = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.languageBtn) {
+			now := time.Now()
+			if now.Sub(g.lastLanguageToggle) > 200*time.Millisecond {
+				g.nextLanguage()
+				g.lastLanguageToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.uiScaleBtn) {
+			now := time.Now()
+			if now.Sub(g.lastUIScaleToggle) > 200*time.Millisecond {
+				g.nextUIScale()
+				g.lastUIScaleToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.leftHandedBtn) {
+			now := time.Now()
+			if now.Sub(g.lastLeftHandedToggle) > 200*time.Millisecond {
+				g.leftHanded = !g.leftHanded
+				g.lastLeftHandedToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.chatTimeBtn) {
+			now := time.Now()
+			if now.Sub(g.lastChatTimeToggle) > 200*time.Millisecond {
+				g.chatTimestamps = !g.chatTimestamps
+				g.lastChatTimeToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.coloredNamesBtn) {
+			now := time.Now()
+			if now.Sub(g.lastNamesToggle) > 200*time.Millisecond {
+				g.coloredNames = !g.coloredNames
+				g.lastNamesToggle = now
+				g.saveSettings()
+			}
+		}
+
+		if pt.In(g.spectatorChatBtn) {
+			now := time.Now()
+			if now.Sub(g.lastSpectatorToggle) > 200*time.Millisecond {
+				g.hideSpectators = !g.hideSpectators
+				g.lastSpectatorToggle = now
+				g.saveSettings()
 			}
 		}
 
 		if pt.In(g.backBtn) {
+			g.saveSettings()
 			g.state = "mainmenu"
 		}
 	}
@@ -1067,10 +2652,237 @@ func (g *Game) updateSettings() {
 	}
 
 	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		g.saveSettings()
 		g.state = "mainmenu"
 	}
 }
 
+// nextLanguage переключает язык интерфейса на следующий из languages
+func (g *Game) nextLanguage() {
+	for i, l := range languages {
+		if l == uiLang {
+			uiLang = languages[(i+1)%len(languages)]
+			return
+		}
+	}
+	uiLang = languages[0]
+}
+
+// nextChatSize переключает размер панели чата на следующий из chatSizePresets
+func (g *Game) nextChatSize() {
+	next := 0
+	for i, p := range chatSizePresets {
+		if p[0] == g.chatWidth && p[1] == g.chatHeight {
+			next = (i + 1) % len(chatSizePresets)
+			break
+		}
+	}
+	g.chatWidth, g.chatHeight = chatSizePresets[next][0], chatSizePresets[next][1]
+}
+
+// saveSession записывает данные подключения в sessionFile
+func saveSession(s LastSession) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		log.Println("Ошибка сохранения сессии:", err)
+		return
+	}
+	if err := os.WriteFile(sessionFile, data, 0644); err != nil {
+		log.Println("Ошибка сохранения сессии:", err)
+	}
+}
+
+// loadSession читает sessionFile; ok = false, если файла нет, он повреждён
+// или подключение было раньше sessionMaxAge
+func loadSession() (LastSession, bool) {
+	var s LastSession
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return s, false
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, false
+	}
+	if s.Server != serverAddr || s.Name == "" || time.Since(time.Unix(s.Time, 0)) > sessionMaxAge {
+		return s, false
+	}
+	return s, true
+}
+
+// clearSession удаляет sessionFile – игрок вышел из матча сам
+func clearSession() {
+	if err := os.Remove(sessionFile); err != nil && !os.IsNotExist(err) {
+		log.Println("Ошибка удаления файла сессии:", err)
+	}
+}
+
+// rejoinSession подключается с персонажем из sessionFile.
+// Если сессия устарела, убирает кнопку «Вернуться в матч» из меню.
+func (g *Game) rejoinSession() {
+	s, ok := loadSession()
+	g.removeMainMenuButton(rejoinButtonText)
+	if !ok {
+		return
+	}
+	serverAddr = defaultServerAddr
+	g.practice = false
+
+	colorIdx := -1
+	for i, c := range g.charColors {
+		if c.R == s.Color.R && c.G == s.Color.G && c.B == s.Color.B && c.A == s.Color.A {
+			colorIdx = i
+			break
+		}
+	}
+
+	g.charName = s.Name
+	g.charRace = s.Race
+	g.charWeapon = s.Weapon
+	g.charSelectedColor = colorIdx
+	g.colorsFetched = false
+	g.charError = ""
+	g.charConnecting = false
+	g.state = "character"
+	if colorIdx < 0 {
+		g.charError = tr("char.no_color")
+		return
+	}
+	g.updatePreview()
+	g.connect()
+}
+
+// startPractice запускает сервер в этом же процессе на свободном локальном порту
+// (при первом вызове) и открывает меню персонажа для подключения к нему
+func (g *Game) startPractice() {
+	if practiceAddr == "" {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			log.Println("Не удалось выбрать порт для тренировки:", err)
+			return
+		}
+		addr := ln.Addr().String()
+		ln.Close()
+
+		// Тренировка не должна трогать файлы банов и снимков рядом с клиентом
+		fs := flag.NewFlagSet("practice", flag.ContinueOnError)
+		gameserver.RegisterFlags(fs)
+		if err := fs.Parse([]string{"-ban-file=", "-snapshot-interval=0"}); err != nil {
+			log.Println("Ошибка настроек сервера тренировки:", err)
+			return
+		}
+		go func() {
+			log.Println("Сервер тренировки остановлен:", gameserver.StartServer(addr))
+		}()
+		practiceAddr = addr
+	}
+
+	serverAddr = practiceAddr
+	g.practice = true
+	g.charSelectedColor = -1
+	g.colorsFetched = false
+	g.charError = ""
+	g.charConnecting = false
+	g.state = "character"
+}
+
+// removeMainMenuButton убирает кнопку главного меню по надписи
+func (g *Game) removeMainMenuButton(label string) {
+	for i, b := range g.mainMenuButtons {
+		if b.Text == label {
+			g.mainMenuButtons = append(g.mainMenuButtons[:i], g.mainMenuButtons[i+1:]...)
+			g.mainMenuButtonRects = g.mainMenuButtonRects[:len(g.mainMenuButtons)]
+			if g.mainMenuSelected >= len(g.mainMenuButtons) {
+				g.mainMenuSelected = 0
+			}
+			return
+		}
+	}
+}
+
+// defaultSettings – настройки при первом запуске
+func defaultSettings() Settings {
+	return Settings{
+		Volume:        50,
+		Fullscreen:    false,
+		ShowSpawnZone: true,
+		ChatWidth:     defaultChatWidth,
+		ChatHeight:    defaultChatHeight,
+		ChatCorner:    "left",
+		Language:      "ru",
+		UIScale:       1,
+		WindowWidth:   screenW,
+		WindowHeight:  screenH,
+	}
+}
+
+// loadSettings читает настройки из settingsFile; при ошибке возвращает значения по умолчанию.
+// Поля, которых нет в файле, сохраняют значения по умолчанию.
+func loadSettings() Settings {
+	s := defaultSettings()
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return s
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Println("Ошибка чтения настроек:", err)
+		return defaultSettings()
+	}
+	if s.Volume < 0 {
+		s.Volume = 0
+	}
+	if s.Volume > 100 {
+		s.Volume = 100
+	}
+	s.ChatWidth = max(250, min(s.ChatWidth, screenW/2))
+	s.ChatHeight = max(150, min(s.ChatHeight, screenH-100))
+	if s.ChatCorner != "right" {
+		s.ChatCorner = "left"
+	}
+	if _, ok := translations[s.Language]; !ok {
+		s.Language = "ru"
+	}
+	if maxScale := uiScalePresets[len(uiScalePresets)-1]; s.UIScale > maxScale {
+		s.UIScale = maxScale
+	} else if !slices.Contains(uiScalePresets, s.UIScale) {
+		s.UIScale = 1
+	}
+	if !slices.Contains(windowSizePresets, [2]int{s.WindowWidth, s.WindowHeight}) {
+		s.WindowWidth, s.WindowHeight = screenW, screenH
+	}
+	return s
+}
+
+// saveSettings сохраняет текущие настройки в settingsFile
+func (g *Game) saveSettings() {
+	s := Settings{
+		Volume:         g.volume,
+		Fullscreen:     g.savedFullscreen,
+		ConfirmActions: g.confirmActions,
+		ShowGrid:       g.showGrid,
+		ShowSpawnZone:  g.showSpawnZone,
+		ChatWidth:      g.chatWidth,
+		ChatHeight:     g.chatHeight,
+		ChatCorner:     g.chatCorner,
+		Language:       uiLang,
+		UIScale:        g.uiScale,
+		LeftHanded:     g.leftHanded,
+		ChatTimestamps: g.chatTimestamps,
+		HideSpectators: g.hideSpectators,
+		ColoredNames:   g.coloredNames,
+		Performance:    g.perfMode,
+		WindowWidth:    g.windowW,
+		WindowHeight:   g.windowH,
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		log.Println("Ошибка сохранения настроек:", err)
+		return
+	}
+	if err := os.WriteFile(settingsFile, data, 0644); err != nil {
+		log.Println("Ошибка сохранения настроек:", err)
+	}
+}
+
 // updateCharacterMenu обновляет логику меню создания персонажа
 func (g *Game) updateCharacterMenu() error {
 	if g.charSelectedColor == -1 && !g.charConnecting {
@@ -1090,47 +2902,56 @@ func (g *Game) updateCharacterMenu() error {
 			}
 			g.updatePreview()
 
-			races := []string{"human", "cat"}
-			g.charRace = races[rand.Intn(len(races))]
+			g.charRace = races[rand.Intn(len(races))].ID
 		}
 	}
 
+	if !g.serverStatusFetching && time.Since(g.lastStatusFetch) > serverStatusInterval {
+		g.serverStatusFetching = true
+		g.lastStatusFetch = time.Now()
+		go g.fetchServerStatus()
+	}
+
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
 		pt := image.Pt(x, y)
 
 		if pt.In(g.charNameInputRect) {
 			g.charNameEdit = true
+			g.charFocus = charFocusName
 		} else {
 			g.charNameEdit = false
 		}
 
-		if pt.In(g.charRaceHumanBtn) {
-			g.charRace = "human"
-			g.updatePreview()
-		}
-		if pt.In(g.charRaceCatBtn) {
-			g.charRace = "cat"
-			g.updatePreview()
+		for i, rect := range g.charRaceBtns {
+			if pt.In(rect) {
+				g.charRace = races[i].ID
+				g.charFocus = charFocusRace
+				g.updatePreview()
+				break
+			}
 		}
 		if pt.In(g.charWeaponSwordBtn) {
 			g.charWeapon = "sword"
+			g.charFocus = charFocusWeapon
 		}
 		if pt.In(g.charWeaponSpearBtn) {
 			g.charWeapon = "spear"
+			g.charFocus = charFocusWeapon
 		}
 
 		for i, rect := range g.charColorButtons {
 			if pt.In(rect) {
 				if !g.colorTaken[i] {
 					g.charSelectedColor = i
+					g.charFocus = charFocusColor
 					g.updatePreview()
 				}
 				break
 			}
 		}
 
-		if pt.In(g.charConnectButton) && !g.charConnecting && g.charName != "" && g.charSelectedColor >= 0 {
+		if pt.In(g.charConnectButton) && g.canConnect() {
 			g.connect()
 		}
 	}
@@ -1151,26 +2972,181 @@ func (g *Game) updateCharacterMenu() error {
 					runes = runes[:len(runes)-1]
 					g.charName = string(runes)
 				}
-				g.lastChatMessage = now
+				g.lastChatMessage = now
+			}
+		}
+	}
+
+	g.handleCharacterKeys()
+
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		g.state = "mainmenu"
+		g.charError = ""
+		g.charConnecting = false
+	}
+
+	return nil
+}
+
+// charKeyJustPressed – нажата ли клавиша именно в этом кадре (меню персонажа)
+func (g *Game) charKeyJustPressed(k ebiten.Key) bool {
+	pressed := ebiten.IsKeyPressed(k)
+	was := g.charPrevKeys[k]
+	g.charPrevKeys[k] = pressed
+	return pressed && !was
+}
+
+// handleCharacterKeys – клавиатурное управление меню персонажа:
+// Tab / Shift+Tab переключают фокус, стрелки меняют значение элемента с фокусом,
+// 1/2 – предыдущая/следующая раса, 3/4 – меч/копьё, Enter – подключиться.
+// Пока вводится имя, работают только Tab и Enter.
+func (g *Game) handleCharacterKeys() {
+	tab := g.charKeyJustPressed(ebiten.KeyTab)
+	key1 := g.charKeyJustPressed(ebiten.Key1)
+	key2 := g.charKeyJustPressed(ebiten.Key2)
+	key3 := g.charKeyJustPressed(ebiten.Key3)
+	key4 := g.charKeyJustPressed(ebiten.Key4)
+	left := g.charKeyJustPressed(ebiten.KeyArrowLeft)
+	right := g.charKeyJustPressed(ebiten.KeyArrowRight)
+	up := g.charKeyJustPressed(ebiten.KeyArrowUp)
+	down := g.charKeyJustPressed(ebiten.KeyArrowDown)
+	enterPressed := ebiten.IsKeyPressed(ebiten.KeyEnter)
+	enter := enterPressed && !g.prevEnterPressed
+	g.prevEnterPressed = enterPressed
+
+	if tab {
+		step := 1
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			step = charFocusCount - 1
+		}
+		g.charFocus = (g.charFocus + step) % charFocusCount
+		g.charNameEdit = g.charFocus == charFocusName
+		return
+	}
+
+	if enter {
+		if g.canConnect() {
+			g.connect()
+		}
+		return
+	}
+
+	if g.charNameEdit {
+		return
+	}
+
+	if key1 || key2 || (g.charFocus == charFocusRace && (left || right)) {
+		step := 1
+		if key1 || (!key2 && left) {
+			step = len(races) - 1
+		}
+		for i, r := range races {
+			if r.ID == g.charRace {
+				g.charRace = races[(i+step)%len(races)].ID
+				break
+			}
+		}
+		g.updatePreview()
+	}
+
+	if key3 {
+		g.charWeapon = "sword"
+	}
+	if key4 {
+		g.charWeapon = "spear"
+	}
+	if g.charFocus == charFocusWeapon && (left || right) {
+		if g.charWeapon == "sword" {
+			g.charWeapon = "spear"
+		} else {
+			g.charWeapon = "sword"
+		}
+	}
+
+	// Новое оружие персонаж на предпросмотре показывает взмахом
+	if g.charWeapon != g.previewWeapon {
+		g.previewWeapon = g.charWeapon
+		g.previewSwingAt = time.Now()
+	}
+
+	if g.charFocus == charFocusColor && len(g.charColors) > 0 {
+		step := 0
+		switch {
+		case left:
+			step = -1
+		case right:
+			step = 1
+		case up:
+			step = -5
+		case down:
+			step = 5
+		}
+		if step != 0 {
+			n := len(g.charColors)
+			idx := g.charSelectedColor
+			if idx < 0 {
+				idx = 0
+			}
+			// пропускаем занятые цвета в выбранном направлении
+			for i := 0; i < n; i++ {
+				idx = ((idx+step)%n + n) % n
+				if !g.colorTaken[idx] {
+					g.charSelectedColor = idx
+					g.updatePreview()
+					break
+				}
 			}
 		}
-		if ebiten.IsKeyPressed(ebiten.KeyEnter) && g.charName != "" && g.charSelectedColor >= 0 && !g.charConnecting {
-			g.connect()
-		}
 	}
+}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-		g.state = "mainmenu"
-		g.charError = ""
-		g.charConnecting = false
+// canConnect – можно ли нажать «Подключиться»: персонаж заполнен,
+// а сервер (если уже ответил) доступен и не переполнен
+func (g *Game) canConnect() bool {
+	if g.charName == "" || g.charSelectedColor < 0 || g.charConnecting {
+		return false
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if !g.serverStatusFetched {
+		return true
 	}
+	if !g.serverStatusOK {
+		return false
+	}
+	return g.serverStatus.MaxPlayers == 0 || g.serverStatus.Players < g.serverStatus.MaxPlayers
+}
 
-	return nil
+// fetchServerStatus запрашивает с сервера число игроков и время работы
+func (g *Game) fetchServerStatus() {
+	var status ServerStatus
+	ok := false
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://" + serverAddr + "/stats")
+	if err != nil {
+		log.Println("Не удалось получить состояние сервера:", err)
+	} else {
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			log.Println("Ошибка парсинга состояния сервера:", err)
+		} else {
+			ok = resp.StatusCode == http.StatusOK
+		}
+		resp.Body.Close()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ok {
+		g.serverStatus = status
+	}
+	g.serverStatusOK = ok
+	g.serverStatusFetched = true
+	g.serverStatusFetching = false
 }
 
 // fetchUsedColors запрашивает с сервера список занятых цветов
 func (g *Game) fetchUsedColors() {
-	resp, err := http.Get("http://localhost:8080/colors")
+	resp, err := http.Get("http://" + serverAddr + "/colors")
 	if err != nil {
 		log.Println("Не удалось получить список цветов:", err)
 		return
@@ -1211,10 +3187,10 @@ func (g *Game) connect() {
 	g.charConnecting = true
 	g.charError = ""
 
-	u := url.URL{Scheme: "ws", Host: "localhost:8080", Path: "/ws"}
+	u := url.URL{Scheme: "ws", Host: serverAddr, Path: "/ws"}
 	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
 	if err != nil {
-		g.charError = "Ошибка подключения: " + err.Error()
+		g.charError = fmt.Sprintf(tr("char.conn_err"), err)
 		g.charConnecting = false
 		return
 	}
@@ -1223,20 +3199,51 @@ func (g *Game) connect() {
 	col := g.charColors[g.charSelectedColor]
 	netColor := NetColor{R: col.R, G: col.G, B: col.B, A: col.A}
 
+	// Сервер повторяет историю чата только новее since – уже виденное не дублируется
+	g.mu.Lock()
+	if g.chatServer != serverAddr {
+		g.chatHistory = g.chatHistory[:0]
+		g.chatServer = serverAddr
+	}
+	var since int64
+	if n := len(g.chatHistory); n > 0 {
+		since = g.chatHistory[n-1].Time
+	}
+	g.mu.Unlock()
+
 	err = conn.WriteJSON(map[string]interface{}{
 		"name":   g.charName,
 		"race":   g.charRace,
 		"weapon": g.charWeapon,
 		"color":  netColor,
+		"since":  since,
 	})
 	if err != nil {
-		g.charError = "Ошибка отправки данных: " + err.Error()
+		g.charError = fmt.Sprintf(tr("char.send_err"), err)
 		g.charConnecting = false
 		conn.Close()
 		g.conn = nil
 		return
 	}
 
+	g.mu.Lock()
+	g.connPhase = phaseConnecting
+	g.connectionLost = false
+	g.state = "game"
+	g.mu.Unlock()
+
+	// Сервер тренировки живёт только вместе с клиентом – возвращаться в него нечего
+	if !g.practice {
+		saveSession(LastSession{
+			Server: serverAddr,
+			Name:   g.charName,
+			Race:   g.charRace,
+			Weapon: g.charWeapon,
+			Color:  netColor,
+			Time:   time.Now().Unix(),
+		})
+	}
+
 	go g.readLoop()
 }
 
@@ -1245,24 +3252,34 @@ func (g *Game) updateGame() error {
 	g.mu.RLock()
 	connected := g.connected
 	ready := g.ready
-	myTurn := g.myTurn
+	myTurn := g.myTurn && !g.actedThisTurn
 	g.mu.RUnlock()
 
 	if !connected && g.connectionLost {
-		if time.Since(g.disconnectTime) > 3*time.Second {
-			g.disconnect()
-			g.state = "mainmenu"
-			g.connectionLost = false
-			return nil
-		}
+		g.handleLostScreen()
 		return nil
 	}
 
 	if !ready || g.id == "" {
+		// Esc на экране загрузки отменяет подключение
+		if ebiten.IsKeyPressed(ebiten.KeyEscape) && !g.prevEscPressed {
+			g.disconnect()
+			g.charConnecting = false
+			g.state = "character"
+		}
 		return nil
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyEscape) && !g.prevEscPressed && !g.chatOpen && !g.showQuitConfirm {
+	rightPressed := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight)
+	escJustPressed := ebiten.IsKeyPressed(ebiten.KeyEscape) && !g.prevEscPressed
+	if g.pendingAction != nil && (!myTurn || (rightPressed && !g.prevRightMouse) || (escJustPressed && !g.chatOpen)) {
+		// отмена выбранного действия; Esc в этом случае не открывает меню
+		g.pendingAction = nil
+		escJustPressed = false
+	}
+	g.prevRightMouse = rightPressed
+
+	if escJustPressed && !g.chatOpen && !g.showQuitConfirm {
 		g.showQuitConfirm = true
 	}
 
@@ -1285,13 +3302,49 @@ func (g *Game) updateGame() error {
 		}
 	}
 
+	// F2 работает только при открытой отладке, чтобы не сбить частоту кадров случайно
+	if g.showDebug && ebiten.IsKeyPressed(ebiten.KeyF2) {
+		now := time.Now()
+		if now.Sub(g.lastF2Press) > 200*time.Millisecond {
+			g.setUncapped(!g.uncapped)
+			g.lastF2Press = now
+		}
+	}
+
+	if ebiten.IsKeyPressed(ebiten.KeyG) && !g.chatOpen {
+		now := time.Now()
+		if now.Sub(g.lastGridToggle) > 200*time.Millisecond {
+			g.showGrid = !g.showGrid
+			g.lastGridToggle = now
+			g.saveSettings()
+		}
+	}
+
 	if myTurn && ebiten.IsKeyPressed(ebiten.KeySpace) {
 		now := time.Now()
 		if now.Sub(g.lastMove) > 200*time.Millisecond {
-			g.conn.WriteJSON(map[string]any{
-				"action": "turn_action",
-				"type":   "skip",
-			})
+			g.sendTurnAction(TurnAction{Kind: "skip"})
+			g.lastMove = now
+		}
+	}
+
+	if myTurn && ebiten.IsKeyPressed(ebiten.KeyB) && !g.chatOpen {
+		now := time.Now()
+		if now.Sub(g.lastMove) > 200*time.Millisecond {
+			g.sendTurnAction(TurnAction{Kind: "defend"})
+			g.lastMove = now
+		}
+	}
+
+	if myTurn && ebiten.IsKeyPressed(ebiten.KeyV) && !g.chatOpen {
+		now := time.Now()
+		if now.Sub(g.lastMove) > 200*time.Millisecond {
+			// в дозор встают только с оружием, бьющим дальше соседней клетки
+			if clientWeaponRange(g.charWeapon) > 1 {
+				g.sendTurnAction(TurnAction{Kind: "overwatch"})
+			} else {
+				g.showToast(tr("toast.no_overwatch"))
+			}
 			g.lastMove = now
 		}
 	}
@@ -1306,6 +3359,17 @@ func (g *Game) updateGame() error {
 		return nil
 	}
 
+	if myTurn {
+		if _, yoff := ebiten.Wheel(); yoff != 0 {
+			step := 1
+			if yoff > 0 {
+				step = actionModeCount - 1
+			}
+			g.actionMode = (g.actionMode + step) % actionModeCount
+			g.pendingAction = nil
+		}
+	}
+
 	g.mu.Lock()
 	myPlayer := g.myPlayer
 	if myPlayer != nil && myTurn {
@@ -1318,29 +3382,34 @@ func (g *Game) updateGame() error {
 		myTileX := int(myPlayer.X / tileSize)
 		myTileY := int(myPlayer.Y / tileSize)
 
-		attackRange := 1
-		if g.charWeapon == "spear" {
-			attackRange = 2
-		}
-
-		hoveredID := ""
+		hoveredID, blockedID := "", ""
 		for _, pl := range g.players {
 			if pl.ID != g.id {
 				plTileX := int(pl.X / tileSize)
 				plTileY := int(pl.Y / tileSize)
 				if plTileX == tileX && plTileY == tileY {
-					dx := math.Abs(float64(tileX - myTileX))
-					dy := math.Abs(float64(tileY - myTileY))
-					if dx+dy <= float64(attackRange) && !(dx == 0 && dy == 0) {
+					if g.canAttack(myTileX, myTileY, tileX-myTileX, tileY-myTileY) {
 						hoveredID = pl.ID
+					} else if g.diagonalReach(tileX-myTileX, tileY-myTileY) {
+						blockedID = pl.ID
 					}
 					break
 				}
 			}
 		}
 		g.hoveredEnemyID = hoveredID
+		g.cornerBlockedID = blockedID
+
+		// курсор показывает то же, что сделает клик
+		if a, reason := g.clickAction(tileX, tileY, myTileX, myTileY); reason != "" {
+			g.cursorKind = "forbidden"
+		} else {
+			g.cursorKind = a.Kind
+		}
 	} else {
 		g.hoveredEnemyID = ""
+		g.cornerBlockedID = ""
+		g.cursorKind = ""
 	}
 	g.mu.Unlock()
 
@@ -1356,56 +3425,14 @@ func (g *Game) updateGame() error {
 			myTileX := int(g.myPlayer.X / tileSize)
 			myTileY := int(g.myPlayer.Y / tileSize)
 
-			attackRange := 1
-			if g.charWeapon == "spear" {
-				attackRange = 2
-			}
-
-			var targetPlayer *Player
-			for _, pl := range g.players {
-				if pl.ID != g.id {
-					plTileX := int(pl.X / tileSize)
-					plTileY := int(pl.Y / tileSize)
-					if plTileX == tileX && plTileY == tileY {
-						targetPlayer = pl
-						break
-					}
-				}
-			}
-
-			if targetPlayer != nil {
-				dx := math.Abs(float64(tileX - myTileX))
-				dy := math.Abs(float64(tileY - myTileY))
-				if dx+dy <= float64(attackRange) && !(dx == 0 && dy == 0) {
-					g.conn.WriteJSON(map[string]any{
-						"action":   "turn_action",
-						"type":     "attack",
-						"targetID": targetPlayer.ID,
-					})
-					g.mu.Lock()
-					if g.myPlayer != nil {
-						g.myPlayer.AttackAnimStart = time.Now()
-						g.myPlayer.AttackAnimTargetID = targetPlayer.ID
-						g.myPlayer.AttackAnimType = g.charWeapon
-						g.myPlayer.AttackAnimProgress = 0.0
-					}
-					g.mu.Unlock()
-				}
+			if a, reason := g.clickAction(tileX, tileY, myTileX, myTileY); reason != "" {
+				g.showToast(tr(reason))
+				g.mu.Lock()
+				g.rejectedTileX, g.rejectedTileY = tileX, tileY
+				g.rejectedAt = time.Now()
+				g.mu.Unlock()
 			} else {
-				dx := tileX - myTileX
-				dy := tileY - myTileY
-				if math.Abs(float64(dx)) <= 1 && math.Abs(float64(dy)) <= 1 && !(dx == 0 && dy == 0) {
-					if tileX >= 0 && tileX < len(g.gameMap[0]) && tileY >= 0 && tileY < len(g.gameMap) && g.gameMap[tileY][tileX] == 0 {
-						targetWorldX := float64(tileX*tileSize + tileSize/2)
-						targetWorldY := float64(tileY*tileSize + tileSize/2)
-						g.conn.WriteJSON(map[string]any{
-							"action":  "turn_action",
-							"type":    "move",
-							"targetX": targetWorldX,
-							"targetY": targetWorldY,
-						})
-					}
-				}
+				g.submitAction(a)
 			}
 		}
 	}
@@ -1415,13 +3442,18 @@ func (g *Game) updateGame() error {
 	for _, pl := range g.players {
 		if pl.Initialized {
 			if pl.Moving {
+				duration := pl.MoveSeconds
+				if duration <= 0 {
+					duration = moveDuration
+				}
 				elapsed := now.Sub(pl.MoveStartTime).Seconds()
-				if elapsed >= moveDuration {
+				// в режиме производительности перемещение не анимируется
+				if elapsed >= duration || g.perfMode {
 					pl.X = pl.MoveEndX
 					pl.Y = pl.MoveEndY
 					pl.Moving = false
 				} else {
-					t := elapsed / moveDuration
+					t := elapsed / duration
 					pl.X = pl.MoveStartX + (pl.MoveEndX-pl.MoveStartX)*t
 					pl.Y = pl.MoveStartY + (pl.MoveEndY-pl.MoveStartY)*t
 				}
@@ -1432,6 +3464,12 @@ func (g *Game) updateGame() error {
 				}
 			}
 
+			if g.perfMode {
+				pl.DisplayHP = float64(pl.HP)
+			} else {
+				pl.DisplayHP = lerpHP(pl.DisplayHP, pl.HP)
+			}
+
 			if !pl.AttackAnimStart.IsZero() {
 				elapsed := now.Sub(pl.AttackAnimStart).Seconds()
 				if elapsed >= attackAnimDuration {
@@ -1444,6 +3482,20 @@ func (g *Game) updateGame() error {
 			}
 		}
 	}
+	for id, pl := range g.fadingPlayers {
+		if now.Sub(pl.FadeStart).Seconds() >= deathFadeSeconds {
+			delete(g.fadingPlayers, id)
+			continue
+		}
+		pl.DisplayHP = lerpHP(pl.DisplayHP, pl.HP)
+	}
+	active := g.damageIndicators[:0]
+	for _, d := range g.damageIndicators {
+		if now.Sub(d.Start).Seconds() < damageIndicatorSeconds {
+			active = append(active, d)
+		}
+	}
+	g.damageIndicators = active
 	g.mu.Unlock()
 
 	if me := g.myPlayer; me != nil {
@@ -1470,6 +3522,8 @@ func (g *Game) updateGame() error {
 		if math.Abs(diff) < 0.01 {
 			g.mySwordCurrentAngle = g.mySwordTargetAngle
 		}
+	} else if g.spectating {
+		g.updateSpectator(leftPressed && !g.prevLeftMouse)
 	}
 
 	if now.Sub(g.chatCursorTimer) > 500*time.Millisecond {
@@ -1482,6 +3536,150 @@ func (g *Game) updateGame() error {
 	return nil
 }
 
+// clickAction решает, что означает клик по клетке в свой ход. Атака выбирается только
+// если в клетке враг в зоне удара, иначе клик – попытка хода. Если клик ничего не даст,
+// вместо действия возвращается ключ причины для всплывающей подсказки – ход не тратится
+func (g *Game) clickAction(tileX, tileY, myTileX, myTileY int) (TurnAction, string) {
+	a := TurnAction{TileX: tileX, TileY: tileY}
+	switch g.actionMode {
+	case actionSkip:
+		a.Kind = "skip"
+		return a, ""
+	case actionHeal:
+		a.Kind = "heal"
+		return a, ""
+	}
+
+	var targetPlayer *Player
+	for _, pl := range g.players {
+		if pl.ID != g.id {
+			plTileX := int(pl.X / tileSize)
+			plTileY := int(pl.Y / tileSize)
+			if plTileX == tileX && plTileY == tileY {
+				targetPlayer = pl
+				break
+			}
+		}
+	}
+	dx, dy := tileX-myTileX, tileY-myTileY
+
+	if targetPlayer != nil {
+		if g.actionMode == actionMove {
+			return a, "toast.occupied"
+		}
+		if !g.canAttack(myTileX, myTileY, dx, dy) {
+			return a, "toast.out_of_reach"
+		}
+		a.Kind = "attack"
+		a.TargetID = targetPlayer.ID
+		return a, ""
+	}
+	if g.actionMode == actionAttack {
+		return a, "toast.no_target"
+	}
+	if math.Abs(float64(dx)) > 1 || math.Abs(float64(dy)) > 1 || (dx == 0 && dy == 0) {
+		return a, "toast.too_far"
+	}
+	if !g.isFreeTile(tileX, tileY) {
+		return a, "toast.blocked"
+	}
+	a.Kind = "move"
+	return a, ""
+}
+
+// showToast показывает короткую всплывающую подсказку над полем
+func (g *Game) showToast(msg string) {
+	g.mu.Lock()
+	g.toastText = msg
+	g.toastTime = time.Now()
+	g.mu.Unlock()
+}
+
+// submitAction выполняет действие сразу или, если включено подтверждение,
+// сначала только выбирает его и ждёт повторного клика по той же клетке
+func (g *Game) submitAction(a TurnAction) {
+	if g.confirmActions {
+		p := g.pendingAction
+		if p == nil || p.Kind != a.Kind || p.TileX != a.TileX || p.TileY != a.TileY || p.TargetID != a.TargetID {
+			g.pendingAction = &a
+			return
+		}
+		g.pendingAction = nil
+	}
+	g.sendTurnAction(a)
+}
+
+// sendTurnAction отправляет действие хода на сервер – не больше одного за ход
+// и только если по последнему состоянию сейчас мой ход
+func (g *Game) sendTurnAction(a TurnAction) {
+	g.mu.Lock()
+	allowed := g.myTurn && !g.actedThisTurn
+	if allowed {
+		g.actedThisTurn = true
+	}
+	g.mu.Unlock()
+	if !allowed {
+		return
+	}
+
+	switch a.Kind {
+	case "attack":
+		g.conn.WriteJSON(map[string]any{
+			"action":   "turn_action",
+			"type":     "attack",
+			"targetID": a.TargetID,
+		})
+		g.mu.Lock()
+		if g.myPlayer != nil {
+			g.myPlayer.AttackAnimStart = time.Now()
+			g.myPlayer.AttackAnimTargetID = a.TargetID
+			g.myPlayer.AttackAnimType = g.charWeapon
+			g.myPlayer.AttackAnimProgress = 0.0
+		}
+		g.mu.Unlock()
+	case "move":
+		g.conn.WriteJSON(map[string]any{
+			"action":  "turn_action",
+			"type":    "move",
+			"targetX": float64(a.TileX*tileSize + tileSize/2),
+			"targetY": float64(a.TileY*tileSize + tileSize/2),
+		})
+		g.predictMove(a.TileX, a.TileY)
+	case "skip", "heal", "defend", "overwatch":
+		g.conn.WriteJSON(map[string]any{
+			"action": "turn_action",
+			"type":   a.Kind,
+		})
+	}
+}
+
+// predictMove сразу начинает движение своего игрока в выбранную клетку,
+// не дожидаясь ответа сервера; подтверждение сверяется в reconcileMyPosition
+func (g *Game) predictMove(tileX, tileY int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	me := g.myPlayer
+	if me == nil || !g.myTurn {
+		return
+	}
+	x := float64(tileX*tileSize + tileSize/2)
+	y := float64(tileY*tileSize + tileSize/2)
+	g.predicting = true
+	g.predictedX = x
+	g.predictedY = y
+	g.predictedAt = time.Now()
+
+	me.Moving = true
+	me.MoveStartTime = g.predictedAt
+	me.MoveStartX = me.X
+	me.MoveStartY = me.Y
+	me.MoveEndX = x
+	me.MoveEndY = y
+	me.MoveSeconds = moveDuration
+	me.TargetX = x
+	me.TargetY = y
+}
+
 // handleChatInput обрабатывает ввод в чате
 func (g *Game) handleChatInput() {
 	if g.chatJustOpened {
@@ -1538,9 +3736,81 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		g.drawGame(screen)
 	case "settings":
 		g.drawSettings(screen)
+	case "summary":
+		g.drawSummary(screen)
 	}
 	g.drawQuitConfirm(screen)
 	g.drawDeathScreen(screen)
+	if g.cursorHidden {
+		g.drawCursor(screen)
+	}
+}
+
+// updateCursor прячет системный курсор, пока в мой ход рисуется контекстный,
+// и возвращает его в меню, окнах подтверждения и при открытом чате
+func (g *Game) updateCursor() {
+	g.mu.RLock()
+	kind := g.cursorKind
+	g.mu.RUnlock()
+	hide := kind != "" && g.state == "game" && !g.chatOpen && !g.showQuitConfirm && !g.showDeathScreen
+	if hide == g.cursorHidden {
+		return
+	}
+	g.cursorHidden = hide
+	if hide {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	}
+}
+
+// drawCursor рисует контекстный курсор: прицел над врагом в зоне удара, следы над
+// клеткой, куда можно сходить, перечёркнутый круг там, где клик ничего не даст
+func (g *Game) drawCursor(screen *ebiten.Image) {
+	g.mu.RLock()
+	kind := g.cursorKind
+	g.mu.RUnlock()
+
+	mx, my := ebiten.CursorPosition()
+	x, y := float32(mx), float32(my)
+	outline := color.RGBA{0, 0, 0, 200}
+	switch kind {
+	case "attack":
+		red := color.RGBA{255, 60, 40, 255}
+		vector.StrokeCircle(screen, x, y, 11, 4, outline, true)
+		vector.StrokeCircle(screen, x, y, 11, 2, red, true)
+		for _, d := range [][2]float32{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			vector.StrokeLine(screen, x+d[0]*6, y+d[1]*6, x+d[0]*17, y+d[1]*17, 4, outline, true)
+			vector.StrokeLine(screen, x+d[0]*6, y+d[1]*6, x+d[0]*17, y+d[1]*17, 2, red, true)
+		}
+	case "move":
+		// два следа ботинка
+		green := color.RGBA{120, 230, 120, 255}
+		for _, foot := range [][2]float32{{-5, 4}, {5, -4}} {
+			fx, fy := x+foot[0], y+foot[1]
+			vector.DrawFilledCircle(screen, fx, fy, 5, outline, true)
+			vector.DrawFilledCircle(screen, fx, fy, 4, green, true)
+			vector.DrawFilledCircle(screen, fx, fy+7, 3, outline, true)
+			vector.DrawFilledCircle(screen, fx, fy+7, 2, green, true)
+		}
+	case "heal":
+		green := color.RGBA{80, 220, 100, 255}
+		vector.DrawFilledRect(screen, x-4, y-11, 8, 22, outline, false)
+		vector.DrawFilledRect(screen, x-11, y-4, 22, 8, outline, false)
+		vector.DrawFilledRect(screen, x-3, y-10, 6, 20, green, false)
+		vector.DrawFilledRect(screen, x-10, y-3, 20, 6, green, false)
+	case "skip":
+		gray := color.RGBA{220, 220, 220, 255}
+		vector.StrokeCircle(screen, x, y, 10, 4, outline, true)
+		vector.StrokeCircle(screen, x, y, 10, 2, gray, true)
+		vector.StrokeLine(screen, x, y, x, y-7, 2, gray, true)
+		vector.StrokeLine(screen, x, y, x+5, y, 2, gray, true)
+	default:
+		red := color.RGBA{220, 40, 40, 255}
+		vector.StrokeCircle(screen, x, y, 10, 4, outline, true)
+		vector.StrokeCircle(screen, x, y, 10, 2, red, true)
+		vector.StrokeLine(screen, x-7, y+7, x+7, y-7, 2, red, true)
+	}
 }
 
 // drawDeathScreen отрисовывает экран смерти
@@ -1563,7 +3833,7 @@ func (g *Game) drawDeathScreen(screen *ebiten.Image) {
 	opDialog.GeoM.Translate(float64(g.deathScreenRects.bg.Min.X), float64(g.deathScreenRects.bg.Min.Y))
 	screen.DrawImage(dialogImg, opDialog)
 
-	title := "Вы погибли!"
+	title := tr("death.title")
 	titleBounds := text.BoundString(g.fontFace, title)
 	titleX := g.deathScreenRects.bg.Min.X + (g.deathScreenRects.bg.Dx()-titleBounds.Dx())/2
 	titleY := g.deathScreenRects.bg.Min.Y + 70
@@ -1575,11 +3845,97 @@ func (g *Game) drawDeathScreen(screen *ebiten.Image) {
 	opBtn.GeoM.Translate(float64(g.deathScreenRects.ok.Min.X), float64(g.deathScreenRects.ok.Min.Y))
 	screen.DrawImage(btnImg, opBtn)
 
-	btnText := "В меню"
+	btnText := tr("death.menu")
 	btnBounds := text.BoundString(g.fontFace, btnText)
 	btnX := g.deathScreenRects.ok.Min.X + (g.deathScreenRects.ok.Dx()-btnBounds.Dx())/2
 	btnY := g.deathScreenRects.ok.Min.Y + (g.deathScreenRects.ok.Dy()+btnBounds.Dy())/2
 	text.Draw(screen, btnText, g.fontFace, btnX, btnY, color.Black)
+
+	specImg := ebiten.NewImage(g.deathScreenRects.spectate.Dx(), g.deathScreenRects.spectate.Dy())
+	specImg.Fill(color.RGBA{0xa1, 0x92, 0x59, 255})
+	opSpec := &ebiten.DrawImageOptions{}
+	opSpec.GeoM.Translate(float64(g.deathScreenRects.spectate.Min.X), float64(g.deathScreenRects.spectate.Min.Y))
+	screen.DrawImage(specImg, opSpec)
+
+	specText := tr("death.spectate")
+	specBounds := text.BoundString(g.fontFace, specText)
+	specX := g.deathScreenRects.spectate.Min.X + (g.deathScreenRects.spectate.Dx()-specBounds.Dx())/2
+	specY := g.deathScreenRects.spectate.Min.Y + (g.deathScreenRects.spectate.Dy()+specBounds.Dy())/2
+	text.Draw(screen, specText, g.fontFace, specX, specY, color.Black)
+}
+
+// drawSummary отрисовывает экран итогов матча
+func (g *Game) drawSummary(screen *ebiten.Image) {
+	// пока соединение живо, под полупрозрачной подложкой видно поле в обзоре
+	g.mu.RLock()
+	backdrop := g.connected && g.ready && g.gameMap != nil
+	g.mu.RUnlock()
+	if backdrop {
+		g.drawGame(screen)
+		vector.DrawFilledRect(screen, 0, 0, screenW, screenH, color.NRGBA{0xe5, 0xdb, 0xb8, 0xc8}, false)
+	} else {
+		screen.Fill(color.RGBA{0xe5, 0xdb, 0xb8, 0xff})
+	}
+
+	title := tr("summary.title")
+	bounds := text.BoundString(g.logoFontFace, title)
+	text.Draw(screen, title, g.logoFontFace, (screenW-bounds.Dx())/2, 170, color.RGBA{120, 90, 30, 255})
+
+	winnerText := tr("summary.no_winner")
+	if g.summary.Winner != "" {
+		winnerText = fmt.Sprintf(tr("summary.winner"), g.summary.Winner)
+		if g.summary.WinnerID == g.id {
+			winnerText += tr("summary.you")
+		}
+	}
+	bounds = text.BoundString(g.fontFace, winnerText)
+	text.Draw(screen, winnerText, g.fontFace, (screenW-bounds.Dx())/2, 260, color.Black)
+
+	secs := int(g.summary.Duration)
+	durationText := fmt.Sprintf(tr("summary.duration"), secs/60, secs%60)
+	bounds = text.BoundString(g.fontFace, durationText)
+	text.Draw(screen, durationText, g.fontFace, (screenW-bounds.Dx())/2, 310, color.Black)
+
+	tableX, tableY := screenW/2-400, 380
+	tableW, rowH := 800, 44
+	ebitenutil.DrawRect(screen, float64(tableX), float64(tableY), float64(tableW), float64(rowH), color.RGBA{0xa1, 0x92, 0x59, 0xff})
+	text.Draw(screen, tr("summary.player"), g.fontFace, tableX+20, tableY+32, color.Black)
+	text.Draw(screen, tr("summary.kills"), g.fontFace, tableX+440, tableY+32, color.Black)
+	text.Draw(screen, tr("summary.deaths"), g.fontFace, tableX+640, tableY+32, color.Black)
+	for i, row := range g.summary.Scoreboard {
+		y := tableY + (i+1)*rowH
+		if y+rowH > g.summaryRects.again.Min.Y-20 && g.summaryRects.again.Dy() > 0 {
+			break
+		}
+		rowCol := color.RGBA{0xd8, 0xcc, 0xa0, 0xff}
+		if i%2 == 1 {
+			rowCol = color.RGBA{0xcc, 0xbe, 0x8c, 0xff}
+		}
+		ebitenutil.DrawRect(screen, float64(tableX), float64(y), float64(tableW), float64(rowH), rowCol)
+		nameCol := color.Color(color.Black)
+		if row.Name == g.summary.Winner {
+			nameCol = color.RGBA{150, 100, 0, 255}
+		}
+		text.Draw(screen, row.Name, g.fontFace, tableX+20, y+32, nameCol)
+		text.Draw(screen, fmt.Sprintf("%d", row.Kills), g.fontFace, tableX+440, y+32, color.Black)
+		text.Draw(screen, fmt.Sprintf("%d", row.Deaths), g.fontFace, tableX+640, y+32, color.Black)
+	}
+
+	buttons := []struct {
+		rect  image.Rectangle
+		label string
+	}{
+		{g.summaryRects.again, tr("summary.again")},
+		{g.summaryRects.menu, tr("summary.menu")},
+	}
+	for _, b := range buttons {
+		if b.rect.Dx() == 0 {
+			continue
+		}
+		ebitenutil.DrawRect(screen, float64(b.rect.Min.X), float64(b.rect.Min.Y), float64(b.rect.Dx()), float64(b.rect.Dy()), color.RGBA{0xa1, 0x92, 0x59, 0xff})
+		lb := text.BoundString(g.fontFace, b.label)
+		text.Draw(screen, b.label, g.fontFace, b.rect.Min.X+(b.rect.Dx()-lb.Dx())/2, b.rect.Min.Y+(b.rect.Dy()+lb.Dy())/2, color.Black)
+	}
 }
 
 // drawMainMenu отрисовывает главное меню
@@ -1626,11 +3982,19 @@ func (g *Game) drawMainMenu(screen *ebiten.Image) {
 	text.Draw(screen, title, g.logoFontFace, x, y, color.RGBA{200, 180, 100, 255})
 
 	for i, rect := range g.mainMenuButtonRects {
-		ebitenutil.DrawRect(screen, float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Dx()), float64(rect.Dy()), color.RGBA{100, 80, 50, 200})
-		b := text.BoundString(g.fontFace, g.mainMenuButtons[i].Text)
+		btnCol := color.RGBA{100, 80, 50, 200}
+		textCol := color.Color(color.White)
+		if i == g.mainMenuSelected {
+			btnCol = color.RGBA{150, 120, 60, 230}
+			textCol = color.RGBA{255, 230, 150, 255}
+			vector.StrokeRect(screen, float32(rect.Min.X-3), float32(rect.Min.Y-3), float32(rect.Dx()+6), float32(rect.Dy()+6), 3, color.RGBA{200, 180, 100, 255}, false)
+		}
+		ebitenutil.DrawRect(screen, float64(rect.Min.X), float64(rect.Min.Y), float64(rect.Dx()), float64(rect.Dy()), btnCol)
+		label := tr(g.mainMenuButtons[i].Text)
+		b := text.BoundString(g.fontFace, label)
 		tx := rect.Min.X + (rect.Dx()-b.Dx())/2
 		ty := rect.Min.Y + (rect.Dy()+b.Dy())/2
-		text.Draw(screen, g.mainMenuButtons[i].Text, g.fontFace, tx, ty, color.White)
+		text.Draw(screen, label, g.fontFace, tx, ty, textCol)
 	}
 }
 
@@ -1638,11 +4002,11 @@ func (g *Game) drawMainMenu(screen *ebiten.Image) {
 func (g *Game) drawCharacterMenu(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{0xe5, 0xdb, 0xb8, 0xff})
 
-	title := "Создание персонажа"
+	title := tr("char.title")
 	bounds := text.BoundString(g.fontFace, title)
 	text.Draw(screen, title, g.fontFace, (screenW-bounds.Dx())/2, 100, color.Black)
 
-	nameLabel := "Имя:"
+	nameLabel := tr("char.name")
 	text.Draw(screen, nameLabel, g.fontFace, 200, 180, color.Black)
 	inputRect := image.Rect(400, 140, 900, 200)
 	g.charNameInputRect = inputRect
@@ -1656,36 +4020,28 @@ func (g *Game) drawCharacterMenu(screen *ebiten.Image) {
 	}
 	text.Draw(screen, displayName, g.fontFace, inputRect.Min.X+10, inputRect.Min.Y+45, color.Black)
 
-	raceLabel := "Раса:"
+	raceLabel := tr("char.race")
 	text.Draw(screen, raceLabel, g.fontFace, 200, 280, color.Black)
 
-	humanBtn := image.Rect(400, 240, 600, 300)
-	g.charRaceHumanBtn = humanBtn
-	btnCol := color.RGBA{0xa1, 0x92, 0x59, 0xff}
-	if g.charRace == "human" {
-		btnCol = color.RGBA{0xc0, 0xb0, 0x70, 0xff}
-	}
-	ebitenutil.DrawRect(screen, float64(humanBtn.Min.X), float64(humanBtn.Min.Y), float64(humanBtn.Dx()), float64(humanBtn.Dy()), btnCol)
-	humanText := "Человек"
-	boundsHuman := text.BoundString(g.fontFace, humanText)
-	txHuman := humanBtn.Min.X + (humanBtn.Dx()-boundsHuman.Dx())/2
-	tyHuman := humanBtn.Min.Y + (humanBtn.Dy()+boundsHuman.Dy())/2
-	text.Draw(screen, humanText, g.fontFace, txHuman, tyHuman, color.Black)
-
-	catBtn := image.Rect(620, 240, 820, 300)
-	g.charRaceCatBtn = catBtn
-	btnCol = color.RGBA{0xa1, 0x92, 0x59, 0xff}
-	if g.charRace == "cat" {
-		btnCol = color.RGBA{0xc0, 0xb0, 0x70, 0xff}
+	raceBtnW, raceBtnSpacing := 135, 10
+	var btnCol color.RGBA
+	for i, race := range races {
+		bx := 400 + i*(raceBtnW+raceBtnSpacing)
+		raceBtn := image.Rect(bx, 240, bx+raceBtnW, 300)
+		g.charRaceBtns[i] = raceBtn
+		btnCol = color.RGBA{0xa1, 0x92, 0x59, 0xff}
+		if g.charRace == race.ID {
+			btnCol = color.RGBA{0xc0, 0xb0, 0x70, 0xff}
+		}
+		ebitenutil.DrawRect(screen, float64(raceBtn.Min.X), float64(raceBtn.Min.Y), float64(raceBtn.Dx()), float64(raceBtn.Dy()), btnCol)
+		raceText := tr(race.Label)
+		boundsRace := text.BoundString(g.fontFace, raceText)
+		txRace := raceBtn.Min.X + (raceBtn.Dx()-boundsRace.Dx())/2
+		tyRace := raceBtn.Min.Y + (raceBtn.Dy()+boundsRace.Dy())/2
+		text.Draw(screen, raceText, g.fontFace, txRace, tyRace, color.Black)
 	}
-	ebitenutil.DrawRect(screen, float64(catBtn.Min.X), float64(catBtn.Min.Y), float64(catBtn.Dx()), float64(catBtn.Dy()), btnCol)
-	catText := "Кот"
-	boundsCat := text.BoundString(g.fontFace, catText)
-	txCat := catBtn.Min.X + (catBtn.Dx()-boundsCat.Dx())/2
-	tyCat := catBtn.Min.Y + (catBtn.Dy()+boundsCat.Dy())/2
-	text.Draw(screen, catText, g.fontFace, txCat, tyCat, color.Black)
 
-	weaponLabel := "Оружие:"
+	weaponLabel := tr("char.weapon")
 	text.Draw(screen, weaponLabel, g.fontFace, 200, 380, color.Black)
 
 	swordBtn := image.Rect(400, 340, 600, 400)
@@ -1712,7 +4068,7 @@ func (g *Game) drawCharacterMenu(screen *ebiten.Image) {
 	spearCenterY := float64(spearBtn.Min.Y + spearBtn.Dy()/2)
 	g.drawSpearScaled(screen, spearCenterX, spearCenterY, 0, 1.4, nil)
 
-	colorLabel := "Цвет:"
+	colorLabel := tr("char.color")
 	text.Draw(screen, colorLabel, g.fontFace, 200, 480, color.Black)
 
 	startX, startY := 400, 440
@@ -1744,37 +4100,124 @@ func (g *Game) drawCharacterMenu(screen *ebiten.Image) {
 		centerX := 1000 + float64(tileSize)*2
 		centerY := 300 + float64(tileSize)*2
 
-		if g.charRace == "cat" && g.charSelectedColor >= 0 {
+		if g.charSelectedColor >= 0 {
 			col := g.charColors[g.charSelectedColor]
 			netCol := NetColor{R: col.R, G: col.G, B: col.B, A: col.A}
-			g.drawCatEarsScaled(screen, centerX, centerY, netCol, 4.0)
+			g.drawRaceDecoration(screen, g.charRace, centerX, centerY, netCol, 4.0)
 		}
 
-		weaponOffsetX := 100.0
-		weaponOffsetY := 0.0
-		if g.charWeapon == "sword" {
-			g.drawSwordScaled(screen, centerX+weaponOffsetX, centerY+weaponOffsetY, 0, 2.0, nil)
+		g.drawPreviewWeapon(screen, centerX, centerY)
+	}
+
+	g.drawCharacterFocus(screen)
+
+	g.mu.RLock()
+	status, statusOK, statusFetched := g.serverStatus, g.serverStatusOK, g.serverStatusFetched
+	g.mu.RUnlock()
+	if statusFetched {
+		if statusOK {
+			online := fmt.Sprintf(tr("char.online"), status.Players, status.MaxPlayers)
+			onlineCol := color.RGBA{0, 0x60, 0, 0xff}
+			if status.MaxPlayers > 0 && status.Players >= status.MaxPlayers {
+				online += tr("char.full")
+				onlineCol = color.RGBA{0xb0, 0, 0, 0xff}
+			}
+			text.Draw(screen, online, g.chatFontFace, 1000, 200, onlineCol)
+			text.Draw(screen, fmt.Sprintf(tr("char.uptime"), status.Uptime), g.chatFontFace, 1000, 230, color.Black)
 		} else {
-			g.drawSpearScaled(screen, centerX+weaponOffsetX, centerY+weaponOffsetY, 0, 2.0, nil)
+			text.Draw(screen, tr("char.offline"), g.chatFontFace, 1000, 200, color.RGBA{0xb0, 0, 0, 0xff})
 		}
+	} else {
+		text.Draw(screen, tr("char.checking"), g.chatFontFace, 1000, 200, color.Black)
 	}
 
 	connectBtn := image.Rect(screenW/2-150, 700, screenW/2+150, 780)
 	g.charConnectButton = connectBtn
 	btnCol = color.RGBA{0xa1, 0x92, 0x59, 0xff}
-	if g.charName != "" && g.charSelectedColor >= 0 && !g.charConnecting {
+	if g.canConnect() {
 		btnCol = color.RGBA{0xc0, 0xb0, 0x70, 0xff}
 	}
 	ebitenutil.DrawRect(screen, float64(connectBtn.Min.X), float64(connectBtn.Min.Y), float64(connectBtn.Dx()), float64(connectBtn.Dy()), btnCol)
-	connectText := "Подключиться"
+	connectText := tr("char.connect")
 	boundsConn := text.BoundString(g.fontFace, connectText)
 	txConn := connectBtn.Min.X + (connectBtn.Dx()-boundsConn.Dx())/2
 	tyConn := connectBtn.Min.Y + (connectBtn.Dy()+boundsConn.Dy())/2
 	text.Draw(screen, connectText, g.fontFace, txConn, tyConn, color.Black)
 
 	if g.charError != "" {
-		text.Draw(screen, "Ошибка: "+g.charError, g.fontFace, 200, 850, color.RGBA{255, 0, 0, 255})
+		text.Draw(screen, fmt.Sprintf(tr("char.error"), g.charError), g.fontFace, 200, 850, color.RGBA{255, 0, 0, 255})
+	}
+
+	keysHint := tr("char.keys")
+	boundsHint := text.BoundString(g.chatFontFace, keysHint)
+	text.Draw(screen, keysHint, g.chatFontFace, (screenW-boundsHint.Dx())/2, 960, color.RGBA{0x50, 0x40, 0x20, 0xff})
+}
+
+// drawPreviewWeapon рисует выбранное оружие в руке персонажа на предпросмотре: в покое
+// оно слегка покачивается, а сразу после выбора делает один удар
+func (g *Game) drawPreviewWeapon(screen *ebiten.Image, centerX, centerY float64) {
+	const (
+		scale     = 2.0
+		handX     = tileSize * 1.6 // рука – у правого края персонажа (масштаб предпросмотра 4)
+		handY     = tileSize * 0.6
+		restAngle = -math.Pi / 6
+	)
+	t := float64(time.Now().UnixMilli()) / 1000
+	if g.perfMode {
+		t = 0
+	}
+	angle := restAngle + math.Sin(t*2)*0.08
+	x := centerX + handX
+	y := centerY + handY + math.Sin(t*2)*3
+
+	// Взмах использует ту же анимацию, что и удар в игре
+	var holder *Player
+	if elapsed := time.Since(g.previewSwingAt).Seconds(); elapsed < attackAnimDuration*2 {
+		holder = &Player{
+			AttackAnimType:     g.charWeapon,
+			AttackAnimStart:    g.previewSwingAt,
+			AttackAnimProgress: elapsed / (attackAnimDuration * 2),
+		}
+	}
+	g.drawWeapon(screen, g.charWeapon, x, y, angle, scale, holder)
+}
+
+// drawWeapon рисует оружие игрока по его названию. Неизвестное оружие не рисуется,
+// чтобы не показывать остальным меч в руках у того, кто держит что-то другое
+func (g *Game) drawWeapon(screen *ebiten.Image, weapon string, x, y, angle, scale float64, player *Player) {
+	switch weapon {
+	case "sword":
+		g.drawSwordScaled(screen, x, y, angle, scale, player)
+	case "spear":
+		g.drawSpearScaled(screen, x, y, angle, scale, player)
+	}
+}
+
+// drawCharacterFocus обводит элемент меню персонажа, на котором стоит фокус клавиатуры
+func (g *Game) drawCharacterFocus(screen *ebiten.Image) {
+	var r image.Rectangle
+	switch g.charFocus {
+	case charFocusName:
+		r = g.charNameInputRect
+	case charFocusRace:
+		for _, b := range g.charRaceBtns {
+			r = r.Union(b)
+		}
+	case charFocusWeapon:
+		r = g.charWeaponSwordBtn.Union(g.charWeaponSpearBtn)
+	case charFocusColor:
+		for _, b := range g.charColorButtons {
+			r = r.Union(b)
+		}
+	case charFocusConnect:
+		r = g.charConnectButton
+	}
+	if r.Empty() {
+		return
 	}
+	const pad = 6
+	vector.StrokeRect(screen, float32(r.Min.X-pad), float32(r.Min.Y-pad),
+		float32(r.Dx()+2*pad), float32(r.Dy()+2*pad), 3, color.RGBA{0x40, 0x30, 0x10, 0xff}, false)
 }
 
 // drawSwordScaled рисует меч с заданным масштабом
@@ -1930,20 +4373,38 @@ func (g *Game) drawSettings(screen *ebiten.Image) {
 		btnX, btnY := 300, 350
 		btnW, btnH := 300, 40
 		g.fullscreenBtn = image.Rect(btnX, btnY, btnX+btnW, btnY+btnH)
+		g.windowSizeBtn = image.Rect(btnX+btnW+20, btnY, btnX+2*btnW+20, btnY+btnH)
+	}
+	if g.confirmActionsBtn.Dx() == 0 {
+		g.confirmActionsBtn = image.Rect(250, 420, 850, 460)
+		g.performanceBtn = image.Rect(870, 420, 1470, 460)
+	}
+	if g.spawnZoneBtn.Dx() == 0 {
+		g.spawnZoneBtn = image.Rect(250, 490, 850, 530)
+	}
+	if g.chatSizeBtn.Dx() == 0 {
+		g.chatSizeBtn = image.Rect(250, 560, 850, 600)
+		g.chatCornerBtn = image.Rect(250, 630, 850, 670)
+		g.languageBtn = image.Rect(250, 700, 850, 740)
+		g.uiScaleBtn = image.Rect(250, 770, 850, 810)
+		g.leftHandedBtn = image.Rect(250, 840, 850, 880)
+		g.coloredNamesBtn = image.Rect(870, 840, 1470, 880)
+		g.chatTimeBtn = image.Rect(250, 910, 850, 950)
+		g.spectatorChatBtn = image.Rect(870, 910, 1470, 950)
 	}
 	if g.backBtn.Dx() == 0 {
-		backX, backY := screenW/2-100, 800
+		backX, backY := screenW/2-100, 990
 		backW, backH := 200, 60
 		g.backBtn = image.Rect(backX, backY, backX+backW, backY+backH)
 	}
 
 	screen.Fill(color.RGBA{0xe5, 0xdb, 0xb8, 0xff})
 
-	title := "Настройки"
+	title := tr("settings.title")
 	bounds := text.BoundString(g.fontFace, title)
 	text.Draw(screen, title, g.fontFace, (screenW-bounds.Dx())/2, 100, color.Black)
 
-	volText := fmt.Sprintf("Громкость музыки: %d%%", g.volume)
+	volText := fmt.Sprintf(tr("settings.volume"), g.volume)
 	text.Draw(screen, volText, g.fontFace, 200, 200, color.Black)
 
 	sliderBg := ebiten.NewImage(g.volumeSlider.rect.Dx(), g.volumeSlider.rect.Dy())
@@ -1960,24 +4421,156 @@ func (g *Game) drawSettings(screen *ebiten.Image) {
 	ebitenutil.DrawRect(screen, float64(g.fullscreenBtn.Min.X), float64(g.fullscreenBtn.Min.Y),
 		float64(g.fullscreenBtn.Dx()), float64(g.fullscreenBtn.Dy()), btnCol)
 
-	fullText := "Оконный режим"
+	fullText := tr("settings.windowed")
 	if g.fullscreen {
-		fullText = "Полноэкранный режим"
+		fullText = tr("settings.fullscreen")
 	}
 	boundsFull := text.BoundString(g.fontFace, fullText)
 	txFull := g.fullscreenBtn.Min.X + (g.fullscreenBtn.Dx()-boundsFull.Dx())/2
 	tyFull := g.fullscreenBtn.Min.Y + (g.fullscreenBtn.Dy()+boundsFull.Dy())/2
 	text.Draw(screen, fullText, g.fontFace, txFull, tyFull, color.Black)
 
+	ebitenutil.DrawRect(screen, float64(g.windowSizeBtn.Min.X), float64(g.windowSizeBtn.Min.Y),
+		float64(g.windowSizeBtn.Dx()), float64(g.windowSizeBtn.Dy()), btnCol)
+	windowSizeText := fmt.Sprintf(tr("settings.window_size"), g.windowW, g.windowH)
+	boundsWindowSize := text.BoundString(g.fontFace, windowSizeText)
+	txWindowSize := g.windowSizeBtn.Min.X + (g.windowSizeBtn.Dx()-boundsWindowSize.Dx())/2
+	tyWindowSize := g.windowSizeBtn.Min.Y + (g.windowSizeBtn.Dy()+boundsWindowSize.Dy())/2
+	text.Draw(screen, windowSizeText, g.fontFace, txWindowSize, tyWindowSize, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.confirmActionsBtn.Min.X), float64(g.confirmActionsBtn.Min.Y),
+		float64(g.confirmActionsBtn.Dx()), float64(g.confirmActionsBtn.Dy()), btnCol)
+	confirmText := tr("settings.confirm_off")
+	if g.confirmActions {
+		confirmText = tr("settings.confirm_on")
+	}
+	boundsConfirm := text.BoundString(g.fontFace, confirmText)
+	txConfirm := g.confirmActionsBtn.Min.X + (g.confirmActionsBtn.Dx()-boundsConfirm.Dx())/2
+	tyConfirm := g.confirmActionsBtn.Min.Y + (g.confirmActionsBtn.Dy()+boundsConfirm.Dy())/2
+	text.Draw(screen, confirmText, g.fontFace, txConfirm, tyConfirm, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.performanceBtn.Min.X), float64(g.performanceBtn.Min.Y),
+		float64(g.performanceBtn.Dx()), float64(g.performanceBtn.Dy()), btnCol)
+	perfText := tr("settings.perf_off")
+	if g.perfMode {
+		perfText = tr("settings.perf_on")
+	}
+	boundsPerf := text.BoundString(g.fontFace, perfText)
+	txPerf := g.performanceBtn.Min.X + (g.performanceBtn.Dx()-boundsPerf.Dx())/2
+	tyPerf := g.performanceBtn.Min.Y + (g.performanceBtn.Dy()+boundsPerf.Dy())/2
+	text.Draw(screen, perfText, g.fontFace, txPerf, tyPerf, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.spawnZoneBtn.Min.X), float64(g.spawnZoneBtn.Min.Y),
+		float64(g.spawnZoneBtn.Dx()), float64(g.spawnZoneBtn.Dy()), btnCol)
+	spawnText := tr("settings.spawn_hidden")
+	if g.showSpawnZone {
+		spawnText = tr("settings.spawn_visible")
+	}
+	boundsSpawn := text.BoundString(g.fontFace, spawnText)
+	txSpawn := g.spawnZoneBtn.Min.X + (g.spawnZoneBtn.Dx()-boundsSpawn.Dx())/2
+	tySpawn := g.spawnZoneBtn.Min.Y + (g.spawnZoneBtn.Dy()+boundsSpawn.Dy())/2
+	text.Draw(screen, spawnText, g.fontFace, txSpawn, tySpawn, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.chatSizeBtn.Min.X), float64(g.chatSizeBtn.Min.Y),
+		float64(g.chatSizeBtn.Dx()), float64(g.chatSizeBtn.Dy()), btnCol)
+	chatSizeText := fmt.Sprintf(tr("settings.chat_size"), g.chatWidth, g.chatHeight)
+	boundsChatSize := text.BoundString(g.fontFace, chatSizeText)
+	txChatSize := g.chatSizeBtn.Min.X + (g.chatSizeBtn.Dx()-boundsChatSize.Dx())/2
+	tyChatSize := g.chatSizeBtn.Min.Y + (g.chatSizeBtn.Dy()+boundsChatSize.Dy())/2
+	text.Draw(screen, chatSizeText, g.fontFace, txChatSize, tyChatSize, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.chatCornerBtn.Min.X), float64(g.chatCornerBtn.Min.Y),
+		float64(g.chatCornerBtn.Dx()), float64(g.chatCornerBtn.Dy()), btnCol)
+	chatCornerText := tr("settings.chat_left")
+	if g.chatCorner == "right" {
+		chatCornerText = tr("settings.chat_right")
+	}
+	boundsChatCorner := text.BoundString(g.fontFace, chatCornerText)
+	txChatCorner := g.chatCornerBtn.Min.X + (g.chatCornerBtn.Dx()-boundsChatCorner.Dx())/2
+	tyChatCorner := g.chatCornerBtn.Min.Y + (g.chatCornerBtn.Dy()+boundsChatCorner.Dy())/2
+	text.Draw(screen, chatCornerText, g.fontFace, txChatCorner, tyChatCorner, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.languageBtn.Min.X), float64(g.languageBtn.Min.Y),
+		float64(g.languageBtn.Dx()), float64(g.languageBtn.Dy()), btnCol)
+	languageText := fmt.Sprintf(tr("settings.language"), tr("language.name"))
+	boundsLanguage := text.BoundString(g.fontFace, languageText)
+	txLanguage := g.languageBtn.Min.X + (g.languageBtn.Dx()-boundsLanguage.Dx())/2
+	tyLanguage := g.languageBtn.Min.Y + (g.languageBtn.Dy()+boundsLanguage.Dy())/2
+	text.Draw(screen, languageText, g.fontFace, txLanguage, tyLanguage, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.uiScaleBtn.Min.X), float64(g.uiScaleBtn.Min.Y),
+		float64(g.uiScaleBtn.Dx()), float64(g.uiScaleBtn.Dy()), btnCol)
+	uiScaleText := fmt.Sprintf(tr("settings.ui_scale"), int(math.Round(g.uiScale*100)))
+	boundsUIScale := text.BoundString(g.fontFace, uiScaleText)
+	txUIScale := g.uiScaleBtn.Min.X + (g.uiScaleBtn.Dx()-boundsUIScale.Dx())/2
+	tyUIScale := g.uiScaleBtn.Min.Y + (g.uiScaleBtn.Dy()+boundsUIScale.Dy())/2
+	text.Draw(screen, uiScaleText, g.fontFace, txUIScale, tyUIScale, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.leftHandedBtn.Min.X), float64(g.leftHandedBtn.Min.Y),
+		float64(g.leftHandedBtn.Dx()), float64(g.leftHandedBtn.Dy()), btnCol)
+	leftHandedText := tr("settings.hud_right")
+	if g.leftHanded {
+		leftHandedText = tr("settings.hud_left")
+	}
+	boundsLeftHanded := text.BoundString(g.fontFace, leftHandedText)
+	txLeftHanded := g.leftHandedBtn.Min.X + (g.leftHandedBtn.Dx()-boundsLeftHanded.Dx())/2
+	tyLeftHanded := g.leftHandedBtn.Min.Y + (g.leftHandedBtn.Dy()+boundsLeftHanded.Dy())/2
+	text.Draw(screen, leftHandedText, g.fontFace, txLeftHanded, tyLeftHanded, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.coloredNamesBtn.Min.X), float64(g.coloredNamesBtn.Min.Y),
+		float64(g.coloredNamesBtn.Dx()), float64(g.coloredNamesBtn.Dy()), btnCol)
+	namesText := tr("settings.names_plain")
+	if g.coloredNames {
+		namesText = tr("settings.names_colored")
+	}
+	boundsNames := text.BoundString(g.fontFace, namesText)
+	txNames := g.coloredNamesBtn.Min.X + (g.coloredNamesBtn.Dx()-boundsNames.Dx())/2
+	tyNames := g.coloredNamesBtn.Min.Y + (g.coloredNamesBtn.Dy()+boundsNames.Dy())/2
+	text.Draw(screen, namesText, g.fontFace, txNames, tyNames, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.chatTimeBtn.Min.X), float64(g.chatTimeBtn.Min.Y),
+		float64(g.chatTimeBtn.Dx()), float64(g.chatTimeBtn.Dy()), btnCol)
+	chatTimeText := tr("settings.chat_time_off")
+	if g.chatTimestamps {
+		chatTimeText = tr("settings.chat_time_on")
+	}
+	boundsChatTime := text.BoundString(g.fontFace, chatTimeText)
+	txChatTime := g.chatTimeBtn.Min.X + (g.chatTimeBtn.Dx()-boundsChatTime.Dx())/2
+	tyChatTime := g.chatTimeBtn.Min.Y + (g.chatTimeBtn.Dy()+boundsChatTime.Dy())/2
+	text.Draw(screen, chatTimeText, g.fontFace, txChatTime, tyChatTime, color.Black)
+
+	ebitenutil.DrawRect(screen, float64(g.spectatorChatBtn.Min.X), float64(g.spectatorChatBtn.Min.Y),
+		float64(g.spectatorChatBtn.Dx()), float64(g.spectatorChatBtn.Dy()), btnCol)
+	spectatorText := tr("settings.spectators_on")
+	if g.hideSpectators {
+		spectatorText = tr("settings.spectators_off")
+	}
+	boundsSpectator := text.BoundString(g.fontFace, spectatorText)
+	txSpectator := g.spectatorChatBtn.Min.X + (g.spectatorChatBtn.Dx()-boundsSpectator.Dx())/2
+	tySpectator := g.spectatorChatBtn.Min.Y + (g.spectatorChatBtn.Dy()+boundsSpectator.Dy())/2
+	text.Draw(screen, spectatorText, g.fontFace, txSpectator, tySpectator, color.Black)
+
 	ebitenutil.DrawRect(screen, float64(g.backBtn.Min.X), float64(g.backBtn.Min.Y),
 		float64(g.backBtn.Dx()), float64(g.backBtn.Dy()), color.RGBA{0xa1, 0x92, 0x59, 0xff})
-	backText := "Назад"
+	backText := tr("settings.back")
 	boundsBack := text.BoundString(g.fontFace, backText)
 	txBack := g.backBtn.Min.X + (g.backBtn.Dx()-boundsBack.Dx())/2
 	tyBack := g.backBtn.Min.Y + (g.backBtn.Dy()+boundsBack.Dy())/2
 	text.Draw(screen, backText, g.fontFace, txBack, tyBack, color.Black)
 }
 
+// drawRaceDecoration рисует отличительные черты расы по таблице races
+func (g *Game) drawRaceDecoration(screen *ebiten.Image, race string, centerX, centerY float64, col NetColor, scale float64) {
+	for _, r := range races {
+		if r.ID == race {
+			if r.Draw != nil {
+				r.Draw(g, screen, centerX, centerY, col, scale)
+			}
+			return
+		}
+	}
+}
+
 // drawCatEarsScaled рисует кошачьи уши
 func (g *Game) drawCatEarsScaled(screen *ebiten.Image, centerX, centerY float64, col NetColor, scale float64) {
 	darkCol := color.RGBA{
@@ -2004,6 +4597,45 @@ func (g *Game) drawCatEarsScaled(screen *ebiten.Image, centerX, centerY float64,
 	g.fillTriangle(screen, x1, y1, x2, y2, x3, y3, darkCol)
 }
 
+// drawDogEarsScaled рисует висячие собачьи уши по бокам головы
+func (g *Game) drawDogEarsScaled(screen *ebiten.Image, centerX, centerY float64, col NetColor, scale float64) {
+	darkCol := color.RGBA{
+		R: uint8(float64(col.R) * 0.6),
+		G: uint8(float64(col.G) * 0.6),
+		B: uint8(float64(col.B) * 0.6),
+		A: 255,
+	}
+
+	x1 := centerX - float64(tileSize)/2*scale
+	y1 := centerY - float64(tileSize)/2*scale
+	x2 := centerX - float64(tileSize)/4*scale
+	y2 := centerY - float64(tileSize)/2*scale
+	x3 := centerX - float64(tileSize)/2*scale - float64(tileSize)/6*scale
+	y3 := centerY + float64(tileSize)/8*scale
+	g.fillTriangle(screen, x1, y1, x2, y2, x3, y3, darkCol)
+
+	x1 = centerX + float64(tileSize)/4*scale
+	y1 = centerY - float64(tileSize)/2*scale
+	x2 = centerX + float64(tileSize)/2*scale
+	y2 = centerY - float64(tileSize)/2*scale
+	x3 = centerX + float64(tileSize)/2*scale + float64(tileSize)/6*scale
+	y3 = centerY + float64(tileSize)/8*scale
+	g.fillTriangle(screen, x1, y1, x2, y2, x3, y3, darkCol)
+}
+
+// drawBeakScaled рисует птичий клюв справа от квадрата
+func (g *Game) drawBeakScaled(screen *ebiten.Image, centerX, centerY float64, _ NetColor, scale float64) {
+	beakCol := color.RGBA{255, 170, 30, 255}
+
+	x1 := centerX + float64(tileSize)/2*scale
+	y1 := centerY - float64(tileSize)/6*scale
+	x2 := centerX + float64(tileSize)/2*scale
+	y2 := centerY + float64(tileSize)/6*scale
+	x3 := centerX + float64(tileSize)/2*scale + float64(tileSize)/3*scale
+	y3 := centerY
+	g.fillTriangle(screen, x1, y1, x2, y2, x3, y3, beakCol)
+}
+
 // drawGame отрисовывает игровой экран
 func (g *Game) drawGame(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{20, 20, 40, 255})
@@ -2011,36 +4643,31 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 	g.mu.RLock()
 
 	if !g.connected && g.connectionLost {
-		msg := "❌ Потеряно соединение с сервером"
-		msg2 := "Возврат в меню..."
-
+		msg := tr("game.lost")
 		bounds := text.BoundString(g.fontFace, msg)
-		x := (screenW - bounds.Dx()) / 2
-		y := screenH / 2
-
-		text.Draw(screen, msg, g.fontFace, x, y, color.White)
-		text.Draw(screen, msg2, g.fontFace, x, y+40, color.White)
-
-		g.mu.RUnlock()
-		return
-	}
+		text.Draw(screen, msg, g.fontFace, (screenW-bounds.Dx())/2, screenH/2, color.White)
+
+		for _, b := range []struct {
+			rect  image.Rectangle
+			label string
+		}{
+			{g.lostScreenRects.reconnect, tr("game.reconnect")},
+			{g.lostScreenRects.menu, tr("game.to_menu")},
+		} {
+			ebitenutil.DrawRect(screen, float64(b.rect.Min.X), float64(b.rect.Min.Y),
+				float64(b.rect.Dx()), float64(b.rect.Dy()), color.RGBA{0xa1, 0x92, 0x59, 0xff})
+			lb := text.BoundString(g.fontFace, b.label)
+			text.Draw(screen, b.label, g.fontFace, b.rect.Min.X+(b.rect.Dx()-lb.Dx())/2,
+				b.rect.Min.Y+(b.rect.Dy()+lb.Dy())/2, color.Black)
+		}
+		g.drawConnectionLight(screen, 0, true)
 
-	if !g.ready {
-		msg := "🔄 Подключение к серверу..."
-		bounds := text.BoundString(g.fontFace, msg)
-		x := (screenW - bounds.Dx()) / 2
-		y := screenH / 2
-		text.Draw(screen, msg, g.fontFace, x, y, color.White)
 		g.mu.RUnlock()
 		return
 	}
 
-	if g.gameMap == nil {
-		msg := "🗺️ Загрузка карты..."
-		bounds := text.BoundString(g.fontFace, msg)
-		x := (screenW - bounds.Dx()) / 2
-		y := screenH / 2
-		text.Draw(screen, msg, g.fontFace, x, y, color.White)
+	if !g.ready || g.gameMap == nil || g.connPhase < phasePlaying {
+		g.drawLoadingScreen(screen, g.connPhase)
 		g.mu.RUnlock()
 		return
 	}
@@ -2051,17 +4678,75 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 	for id, pl := range g.players {
 		playersCopy[id] = pl
 	}
+	// погибшие растворяются на поле, если не включён режим производительности
+	fadingCopy := make([]*Player, 0, len(g.fadingPlayers))
+	for _, pl := range g.fadingPlayers {
+		if !g.perfMode {
+			fadingCopy = append(fadingCopy, pl)
+		}
+	}
 	meCopy := me
 	gameMapCopy := g.gameMap
-	camX, camY := g.camX, g.camY
+	camX, camY, zoom := g.camX, g.camY, g.camZoom
 	showDebug := g.showDebug
+	weaponDamage, weaponRange := g.weaponDamage, g.weaponRange
+	showGrid := g.showGrid
+	spawnZone := image.Rectangle{}
+	if g.showSpawnZone {
+		spawnZone = g.spawnZone
+	}
 	myTurn := g.myTurn
 	currentTurn := g.currentTurn
+	turnOrder := append([]string(nil), g.turnOrder...)
 	turnTimeLeft := g.turnTimeLeft
+	waitingForPlayers := g.waitingForPlayers
+	if !g.turnTimeSyncedAt.IsZero() && !waitingForPlayers {
+		turnTimeLeft -= time.Since(g.turnTimeSyncedAt).Seconds()
+	}
 	if turnTimeLeft < 0 {
 		turnTimeLeft = 0
 	}
 	hoveredEnemyID := g.hoveredEnemyID
+	actionMode := g.actionMode
+	toastText, toastTime := g.toastText, g.toastTime
+	perfMode := g.perfMode
+	announceText, announceAt := g.announceText, g.announceAt
+	rejectedTileX, rejectedTileY, rejectedAt := g.rejectedTileX, g.rejectedTileY, g.rejectedAt
+	cornerBlockedID := g.cornerBlockedID
+	// враги, до которых достаёт удар, – по тем же правилам, что и проверка клика
+	var inRange map[string]bool
+	var attackTiles [][2]int
+	if g.myTurn && g.myPlayer != nil {
+		myTileX, myTileY := int(g.myPlayer.X/tileSize), int(g.myPlayer.Y/tileSize)
+		attackTiles = g.attackTiles(myTileX, myTileY)
+		for id, pl := range g.players {
+			if id == g.id {
+				continue
+			}
+			if g.canAttack(myTileX, myTileY, int(pl.X/tileSize)-myTileX, int(pl.Y/tileSize)-myTileY) {
+				if inRange == nil {
+					inRange = make(map[string]bool)
+				}
+				inRange[id] = true
+			}
+		}
+	}
+	spectating := g.spectating
+	spectatorFreeCam := g.spectatorFreeCam
+	spectatorOverview := g.spectatorOverview
+	spectatedID := g.spectatedID()
+	var pendingAction *TurnAction
+	if g.pendingAction != nil {
+		pa := *g.pendingAction
+		pendingAction = &pa
+	}
+	var stateAge time.Duration
+	if !g.lastStateTime.IsZero() {
+		stateAge = time.Since(g.lastStateTime)
+	}
+	lost := g.connectionLost || !g.connected
+	damageIndicators := make([]DamageIndicator, len(g.damageIndicators))
+	copy(damageIndicators, g.damageIndicators)
 
 	chatHistoryCopy := make([]ChatMessage, len(g.chatHistory))
 	copy(chatHistoryCopy, g.chatHistory)
@@ -2073,10 +4758,17 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 
 	g.mu.RUnlock()
 
+	// при обзоре мир рисуется в слой размером с видимую область и уменьшается на экран
+	viewW, viewH := screenW/zoom, screenH/zoom
+	world := screen
+	if zoom < 1 {
+		world = g.worldView(int(math.Ceil(viewW)), int(math.Ceil(viewH)))
+	}
+
 	startX := int(camX/float64(tileSize)) - 2
 	startY := int(camY/float64(tileSize)) - 2
-	endX := int((camX+screenW)/float64(tileSize)) + 3
-	endY := int((camY+screenH)/float64(tileSize)) + 3
+	endX := int((camX+viewW)/float64(tileSize)) + 3
+	endY := int((camY+viewH)/float64(tileSize)) + 3
 
 	if startX < 0 {
 		startX = 0
@@ -2100,11 +4792,20 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 					float64(x*tileSize)-camX,
 					float64(y*tileSize)-camY,
 				)
-				screen.DrawImage(tileImg, op)
+				world.DrawImage(tileImg, op)
+				if image.Pt(x, y).In(spawnZone) {
+					vector.DrawFilledRect(world,
+						float32(float64(x*tileSize)-camX), float32(float64(y*tileSize)-camY),
+						tileSize, tileSize, color.RGBA{60, 60, 0, 60}, false)
+				}
 			}
 		}
 	}
 
+	if showGrid {
+		g.drawGrid(world, startX, startY, endX, endY, camX, camY)
+	}
+
 	if myTurn && meCopy != nil {
 		myTileX := int(meCopy.X / tileSize)
 		myTileY := int(meCopy.Y / tileSize)
@@ -2133,7 +4834,7 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 							highlight.Fill(color.RGBA{0, 40, 0, 20})
 							op := &ebiten.DrawImageOptions{}
 							op.GeoM.Translate(float64(tileX*tileSize)-camX, float64(tileY*tileSize)-camY)
-							screen.DrawImage(highlight, op)
+							world.DrawImage(highlight, op)
 						}
 					}
 				}
@@ -2141,74 +4842,196 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 		}
 	}
 
+	if pendingAction != nil && myTurn {
+		g.drawPendingAction(world, pendingAction, camX, camY)
+	}
+
+	// пока курсор на враге – все клетки, до которых достаёт удар из моей клетки
+	if _, ok := playersCopy[hoveredEnemyID]; ok && myTurn && meCopy != nil {
+		myTileX, myTileY := int(meCopy.X/tileSize), int(meCopy.Y/tileSize)
+		for _, off := range attackTiles {
+			vector.DrawFilledRect(world,
+				float32(float64((myTileX+off[0])*tileSize)-camX), float32(float64((myTileY+off[1])*tileSize)-camY),
+				tileSize, tileSize, color.NRGBA{220, 40, 40, 70}, false)
+		}
+	}
+
+	// Видимость считается один раз за кадр; свой игрок, игрок под курсором,
+	// тот, чей сейчас ход, и тот, за кем следит наблюдатель, не отсекаются никогда
+	visible := make(map[string]bool, len(playersCopy))
+	for id, pl := range playersCopy {
+		visible[id] = pl.IsMe || id == hoveredEnemyID || id == currentTurn || id == spectatedID ||
+			isInView(pl.X-camX, pl.Y-camY, viewW, viewH)
+	}
+
 	for _, pl := range playersCopy {
-		if !pl.Initialized {
+		if !pl.Initialized || !visible[pl.ID] {
 			continue
 		}
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(pl.X-camX-float64(tileSize)/2, pl.Y-camY-float64(tileSize)/2)
-		screen.DrawImage(pl.Image, op)
-		if pl.Race == "cat" {
-			g.drawCatEarsScaled(screen, pl.X-camX, pl.Y-camY, pl.Color, 1.0)
+		world.DrawImage(pl.Image, op)
+		g.drawRaceDecoration(world, pl.Race, pl.X-camX, pl.Y-camY, pl.Color, 1.0)
+		if pl.Poison > 0 {
+			g.drawPoison(world, pl.X-camX, pl.Y-camY, pl.Poison)
+		}
+		if pl.Defending {
+			drawShield(world, pl.X-camX, pl.Y-camY)
 		}
-		if hoveredEnemyID == pl.ID && myTurn && meCopy != nil {
+		if pl.Overwatch {
+			drawOverwatch(world, pl.X-camX, pl.Y-camY)
+		}
+		if hoveredEnemyID == pl.ID && myTurn && meCopy != nil && !perfMode {
 			glowOp := &ebiten.DrawImageOptions{}
 			half := float64(g.glowImage.Bounds().Dx()) / 2
 			glowOp.GeoM.Translate(pl.X-camX-half, pl.Y-camY-half)
-			screen.DrawImage(g.glowImage, glowOp)
+			world.DrawImage(g.glowImage, glowOp)
+		}
+		if inRange[pl.ID] && hoveredEnemyID != pl.ID && myTurn && meCopy != nil {
+			drawInRangeMark(world, meCopy.X-camX, meCopy.Y-camY, pl.X-camX, pl.Y-camY)
+		}
+		if cornerBlockedID == pl.ID && myTurn && meCopy != nil {
+			// красный крест: удар через угол препятствия не пройдёт
+			const arm = tileSize / 2
+			sx, sy := float32(pl.X-camX), float32(pl.Y-camY)
+			blockedColor := color.RGBA{220, 40, 40, 230}
+			vector.StrokeLine(world, sx-arm, sy-arm, sx+arm, sy+arm, 3, blockedColor, true)
+			vector.StrokeLine(world, sx-arm, sy+arm, sx+arm, sy-arm, 3, blockedColor, true)
+		}
+		if spectatedID == pl.ID {
+			const pad = 4
+			vector.StrokeRect(world,
+				float32(pl.X-camX-tileSize/2-pad), float32(pl.Y-camY-tileSize/2-pad),
+				tileSize+2*pad, tileSize+2*pad, 2, color.RGBA{255, 215, 0, 255}, false)
+		}
+	}
+
+	now := time.Now()
+	for _, pl := range fadingCopy {
+		alpha := 1 - now.Sub(pl.FadeStart).Seconds()/deathFadeSeconds
+		if alpha <= 0 || !isInView(pl.X-camX, pl.Y-camY, viewW, viewH) {
+			continue
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(pl.X-camX-float64(tileSize)/2, pl.Y-camY-float64(tileSize)/2)
+		op.ColorScale.ScaleAlpha(float32(alpha))
+		world.DrawImage(pl.Image, op)
+		g.drawHPBar(world, pl.X-camX, pl.Y-camY, pl.DisplayHP)
+	}
+
+	for _, pl := range playersCopy {
+		if !pl.Initialized || !visible[pl.ID] {
+			continue
 		}
+		g.drawHPBar(world, pl.X-camX, pl.Y-camY, pl.DisplayHP)
 	}
 
 	for _, pl := range playersCopy {
-		if !pl.Initialized {
+		if !pl.Initialized || !visible[pl.ID] {
 			continue
 		}
 		nameText := pl.Name
 		nameBounds := text.BoundString(g.nameFontFace, nameText)
 		nameX := int(pl.X-camX) - nameBounds.Dx()/2
 		nameY := int(pl.Y - camY - float64(tileSize) - 20)
-		if pl.IsMe {
-			text.Draw(screen, nameText, g.nameFontFace, nameX+1, nameY+1, color.Black)
-			text.Draw(screen, nameText, g.nameFontFace, nameX, nameY, color.RGBA{173, 216, 230, 255})
+		if g.coloredNames {
+			// обводка со всех сторон, чтобы имя читалось на любом фоне;
+			// у очень тёмного цвета обводка светлая, иначе она сольётся с буквами
+			var outline color.Color = color.Black
+			if lum := 0.299*float64(pl.Color.R) + 0.587*float64(pl.Color.G) + 0.114*float64(pl.Color.B); lum < 70 {
+				outline = color.RGBA{220, 220, 220, 255}
+			}
+			for _, d := range [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}} {
+				text.Draw(world, nameText, g.nameFontFace, nameX+d[0], nameY+d[1], outline)
+			}
+			text.Draw(world, nameText, g.nameFontFace, nameX, nameY, color.RGBA{pl.Color.R, pl.Color.G, pl.Color.B, 255})
+		} else if pl.IsMe {
+			text.Draw(world, nameText, g.nameFontFace, nameX+1, nameY+1, color.Black)
+			text.Draw(world, nameText, g.nameFontFace, nameX, nameY, color.RGBA{173, 216, 230, 255})
 		} else {
-			text.Draw(screen, nameText, g.nameFontFace, nameX, nameY, color.White)
+			text.Draw(world, nameText, g.nameFontFace, nameX, nameY, color.White)
 		}
 	}
 
 	for _, pl := range playersCopy {
-		if pl.IsMe || !pl.Initialized {
+		if pl.IsMe || !pl.Initialized || !visible[pl.ID] {
 			continue
 		}
-		switch pl.Weapon {
-		case "sword":
-			g.drawSwordScaled(screen, pl.X-camX, pl.Y-camY, math.Pi/4, 1.0, pl)
-		case "spear":
-			g.drawSpearScaled(screen, pl.X-camX, pl.Y-camY, math.Pi/4, 1.0, pl)
-		}
+		g.drawWeapon(world, pl.Weapon, pl.X-camX, pl.Y-camY, math.Pi/4, 1.0, pl)
 	}
 
 	if meCopy != nil {
-		if g.charWeapon == "sword" {
-			g.drawSwordScaled(screen, meCopy.X-camX, meCopy.Y-camY, g.mySwordCurrentAngle, 1.0, meCopy)
-		} else {
-			g.drawSpearScaled(screen, meCopy.X-camX, meCopy.Y-camY, g.mySwordCurrentAngle, 1.0, meCopy)
+		// оружие из состояния сервера; до первого состояния – выбранное в меню
+		weapon := meCopy.Weapon
+		if weapon == "" {
+			weapon = g.charWeapon
 		}
+		g.drawWeapon(world, weapon, meCopy.X-camX, meCopy.Y-camY, g.mySwordCurrentAngle, 1.0, meCopy)
+	}
+
+	drawRejectedTile(world, rejectedTileX, rejectedTileY, rejectedAt, camX, camY)
+
+	if zoom < 1 {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(zoom, zoom)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(world, op)
+	}
+	// на экране итогов мир служит только фоном – без интерфейса боя
+	if g.state != "game" {
+		return
+	}
+
+	if meCopy != nil && meCopy.HP > 0 && meCopy.HP <= lowHPThreshold && !perfMode {
+		g.drawLowHPVignette(screen, meCopy.HP)
+	}
+	for _, d := range damageIndicators {
+		g.drawDamageIndicator(screen, d)
 	}
 
 	currentPlayerName := ""
+
 	if p, ok := playersCopy[currentTurn]; ok {
 		currentPlayerName = p.Name
 	}
-	g.drawTurnTimer(screen, turnTimeLeft, myTurn, currentPlayerName)
+	g.drawTurnTimer(screen, turnTimeLeft, myTurn, waitingForPlayers, currentPlayerName)
+	g.drawTurnOrder(screen, turnOrder, playersCopy, currentTurn)
+	if myTurn {
+		g.drawActionMode(screen, actionMode)
+	}
+	if toastText != "" {
+		g.drawToast(screen, toastText, toastTime)
+	}
+	if announceText != "" {
+		g.drawAnnounce(screen, announceText, announceAt)
+	}
+
+	if spectating {
+		hint := tr("spectate.free")
+		if spectatorOverview {
+			hint = tr("spectate.overview")
+		} else if !spectatorFreeCam {
+			name := "—"
+			if p, ok := playersCopy[spectatedID]; ok {
+				name = p.Name
+			}
+			hint = fmt.Sprintf(tr("spectate.follow"), name)
+		}
+		hb := text.BoundString(g.chatFontFace, hint)
+		hx := (screenW - hb.Dx()) / 2
+		text.Draw(screen, hint, g.chatFontFace, hx+1, 41, color.Black)
+		text.Draw(screen, hint, g.chatFontFace, hx, 40, color.White)
+	}
 
 	g.drawChat(screen, chatHistoryCopy, chatOpen, chatBuffer, chatCursor, lastChatMessage, chatCursorTimer)
+	g.drawConnectionLight(screen, stateAge, lost)
 
 	if showDebug {
 		var xCoord, yCoord float64
 		if meCopy != nil {
 			xCoord, yCoord = meCopy.X, meCopy.Y
 		}
-		debugText := fmt.Sprintf("FPS: %.1f | Игроков: %d | X: %.0f Y: %.0f | Оружие: %s",
+		debugText := fmt.Sprintf(tr("debug.stats"),
 			ebiten.ActualFPS(),
 			len(playersCopy),
 			xCoord, yCoord,
@@ -2217,19 +5040,442 @@ func (g *Game) drawGame(screen *ebiten.Image) {
 		if meCopy != nil {
 			debugText += fmt.Sprintf(" | HP: %d", meCopy.HP)
 		}
-		debugText += "\nF1 - отладка | ЛКМ - движение/атака | Space - пропустить ход | T - открыть чат | Esc - закрыть чат/меню | F11 - полноэкранный режим"
+		debugText += fmt.Sprintf(tr("debug.updated"), stateAge.Milliseconds())
+		if g.uncapped {
+			debugText += "\n" + fmt.Sprintf(tr("debug.uncapped"), ebiten.ActualTPS())
+		} else {
+			debugText += "\n" + fmt.Sprintf(tr("debug.vsync"), normalTPS)
+		}
+
+		// Правила боя по данным сервера; расхождение с тем, что считает клиент, видно сразу
+		if weaponRange > 0 {
+			debugText += "\n" + fmt.Sprintf(tr("debug.server_rules"), weaponDamage, weaponRange)
+			if local := clientWeaponRange(g.charWeapon); local != weaponRange {
+				debugText += fmt.Sprintf(tr("debug.client_range"), local)
+			}
+		} else {
+			debugText += "\n" + tr("debug.no_rules")
+		}
+
+		// Координаты под курсором – так же, как при клике в updateGame
+		mx, my := ebiten.CursorPosition()
+		worldX := float64(mx)/zoom + camX
+		worldY := float64(my)/zoom + camY
+		tileX := int(worldX / tileSize)
+		tileY := int(worldY / tileSize)
+		tileName := tr("debug.off_map")
+		if worldX >= 0 && worldY >= 0 && tileY < len(gameMapCopy) && tileX < len(gameMapCopy[0]) {
+			tileName = tileTypeName(gameMapCopy[tileY][tileX])
+		}
+		debugText += "\n" + fmt.Sprintf(tr("debug.cursor"), worldX, worldY, tileX, tileY, tileName)
+		debugText += "\n" + tr("game.help")
 
 		lines := strings.Split(debugText, "\n")
 		for i, line := range lines {
 			text.Draw(screen, line, g.chatFontFace, 20, 40+i*30, color.White)
 		}
+		g.drawFrameGraph(screen, 20, float32(30+len(lines)*30))
+	}
+}
+
+// setUncapped включает режим замеров: без вертикальной синхронизации и с Update на каждый кадр.
+// Анимации, считающие время в кадрах, в этом режиме ускоряются – он не для обычной игры
+func (g *Game) setUncapped(on bool) {
+	g.uncapped = on
+	ebiten.SetVsyncEnabled(!on)
+	if on {
+		ebiten.SetTPS(ebiten.SyncWithFPS)
+	} else {
+		ebiten.SetTPS(normalTPS)
+	}
+}
+
+// recordFrameTime запоминает, сколько прошло с предыдущего вызова Update
+func (g *Game) recordFrameTime() {
+	now := time.Now()
+	if !g.lastFrameAt.IsZero() {
+		g.frameTimes[g.frameTimePos] = float64(now.Sub(g.lastFrameAt).Microseconds()) / 1000
+		g.frameTimePos = (g.frameTimePos + 1) % frameGraphSamples
+	}
+	g.lastFrameAt = now
+}
+
+// drawFrameGraph рисует график времени последних кадров: линии 60 и 30 FPS
+// для ориентира, всплески выше них – подвисания (например, паузы сборщика мусора)
+func (g *Game) drawFrameGraph(screen *ebiten.Image, x, y float32) {
+	vector.DrawFilledRect(screen, x, y, frameGraphW, frameGraphH, color.RGBA{0, 0, 0, 160}, false)
+
+	scaleY := func(ms float64) float32 {
+		return y + frameGraphH - float32(min(ms, frameGraphMaxMS)/frameGraphMaxMS*frameGraphH)
+	}
+	for _, ref := range []float64{1000.0 / 60, 1000.0 / 30} {
+		ry := scaleY(ref)
+		vector.StrokeLine(screen, x, ry, x+frameGraphW, ry, 1, color.RGBA{0x60, 0x60, 0x60, 0xff}, false)
+	}
+
+	step := float32(frameGraphW) / (frameGraphSamples - 1)
+	worst := 0.0
+	for i := 1; i < frameGraphSamples; i++ {
+		// от самого старого кадра к самому свежему
+		prev := g.frameTimes[(g.frameTimePos+i-1)%frameGraphSamples]
+		cur := g.frameTimes[(g.frameTimePos+i)%frameGraphSamples]
+		worst = max(worst, cur)
+		lineCol := color.RGBA{0x40, 0xe0, 0x40, 0xff}
+		if cur > 1000.0/30 {
+			lineCol = color.RGBA{0xff, 0x40, 0x40, 0xff}
+		}
+		vector.StrokeLine(screen, x+float32(i-1)*step, scaleY(prev), x+float32(i)*step, scaleY(cur), 1, lineCol, true)
+	}
+
+	label := fmt.Sprintf(tr("debug.frame_worst"), worst)
+	text.Draw(screen, label, g.chatFontFace, int(x), int(y+frameGraphH)+20, color.White)
+}
+
+// drawGrid рисует линии по границам тайлов в видимой области [startX, endX) × [startY, endY)
+func (g *Game) drawGrid(screen *ebiten.Image, startX, startY, endX, endY int, camX, camY float64) {
+	gridColor := color.RGBA{0, 0, 0, 50}
+	top := float32(float64(startY*tileSize) - camY)
+	bottom := float32(float64(endY*tileSize) - camY)
+	left := float32(float64(startX*tileSize) - camX)
+	right := float32(float64(endX*tileSize) - camX)
+
+	for x := startX; x <= endX; x++ {
+		sx := float32(float64(x*tileSize) - camX)
+		vector.StrokeLine(screen, sx, top, sx, bottom, 1, gridColor, false)
+	}
+	for y := startY; y <= endY; y++ {
+		sy := float32(float64(y*tileSize) - camY)
+		vector.StrokeLine(screen, left, sy, right, sy, 1, gridColor, false)
+	}
+}
+
+// drawPendingAction выделяет клетку, выбранную первым кликом, и подсказывает, как подтвердить
+func (g *Game) drawPendingAction(screen *ebiten.Image, a *TurnAction, camX, camY float64) {
+	col := color.RGBA{80, 200, 255, 255}
+	switch a.Kind {
+	case "attack":
+		col = color.RGBA{255, 80, 40, 255}
+	case "heal":
+		col = color.RGBA{80, 220, 100, 255}
+	case "skip":
+		col = color.RGBA{200, 200, 200, 255}
+	}
+	pulse := 0.5 + 0.5*math.Sin(float64(time.Now().UnixMilli())/150.0)
+	fill := col
+	fill.A = uint8(40 + 50*pulse)
+
+	x := float32(float64(a.TileX*tileSize) - camX)
+	y := float32(float64(a.TileY*tileSize) - camY)
+	vector.DrawFilledRect(screen, x, y, tileSize, tileSize, fill, false)
+	vector.StrokeRect(screen, x, y, tileSize, tileSize, 3, col, false)
+
+	hint := tr("pending.hint")
+	bounds := text.BoundString(g.chatFontFace, hint)
+	hx := (screenW - bounds.Dx()) / 2
+	vector.DrawFilledRect(screen, float32(hx-10), 20, float32(bounds.Dx()+20), 36, color.RGBA{0, 0, 0, 150}, false)
+	text.Draw(screen, hint, g.chatFontFace, hx, 45, color.White)
+}
+
+// drawLoadingScreen рисует вращающийся индикатор и этапы подключения
+func (g *Game) drawLoadingScreen(screen *ebiten.Image, phase int) {
+	steps := []string{tr("loading.connecting"), tr("loading.map"), tr("loading.state")}
+	if phase >= len(steps) {
+		phase = len(steps) - 1
+	}
+
+	cx, cy := float64(screenW)/2, float64(screenH)/2-80
+	const (
+		dots   = 12
+		radius = 40.0
+	)
+	t := float64(time.Now().UnixMilli()) / 1000.0
+	head := int(t*dots) % dots
+	for i := 0; i < dots; i++ {
+		angle := float64(i) / dots * 2 * math.Pi
+		// точки за «головой» постепенно гаснут
+		age := (head - i + dots) % dots
+		alpha := uint8(255 - age*200/dots)
+		x := cx + radius*math.Cos(angle)
+		y := cy + radius*math.Sin(angle)
+		vector.DrawFilledCircle(screen, float32(x), float32(y), 6, color.RGBA{200, 180, 100, alpha}, true)
+	}
+
+	msg := steps[phase] + strings.Repeat(".", int(t*2)%4)
+	bounds := text.BoundString(g.fontFace, steps[phase]+"...")
+	text.Draw(screen, msg, g.fontFace, (screenW-bounds.Dx())/2, int(cy)+110, color.White)
+
+	// шкала этапов: пройденные – золотые, текущий – мигает
+	const (
+		segW   = 160
+		segH   = 10
+		segGap = 12
+	)
+	total := len(steps)*segW + (len(steps)-1)*segGap
+	x0 := (screenW - total) / 2
+	y0 := int(cy) + 150
+	for i := range steps {
+		col := color.RGBA{80, 80, 80, 255}
+		switch {
+		case i < phase:
+			col = color.RGBA{200, 180, 100, 255}
+		case i == phase:
+			pulse := 0.5 + 0.5*math.Sin(t*6)
+			col = color.RGBA{uint8(120 + 80*pulse), uint8(110 + 70*pulse), 70, 255}
+		}
+		vector.DrawFilledRect(screen, float32(x0+i*(segW+segGap)), float32(y0), segW, segH, col, false)
+	}
+}
+
+// drawPoison тонирует отравленного игрока зелёным и рисует в углу капельку с силой яда
+func (g *Game) drawPoison(screen *ebiten.Image, x, y float64, stacks int) {
+	pulse := 0.5 + 0.5*math.Sin(float64(time.Now().UnixMilli())/200.0)
+	vector.DrawFilledRect(screen, float32(x-tileSize/2), float32(y-tileSize/2), tileSize, tileSize,
+		color.NRGBA{60, 200, 60, uint8(60 + 50*pulse)}, false)
+
+	dx, dy := float32(x+tileSize/2), float32(y-tileSize/2)
+	vector.DrawFilledCircle(screen, dx, dy, 9, color.RGBA{40, 160, 40, 255}, true)
+	vector.StrokeCircle(screen, dx, dy, 9, 1.5, color.Black, true)
+	label := fmt.Sprintf("%d", stacks)
+	b := text.BoundString(g.chatFontFace, label)
+	text.Draw(screen, label, g.chatFontFace, int(dx)-b.Dx()/2-1, int(dy)+b.Dy()/2, color.White)
+}
+
+// drawInRangeMark отмечает врага, которого можно ударить в этот ход: бледная линия от меня
+// и пульсирующее кольцо. Враг под курсором вместо этого подсвечивается ярче
+func drawInRangeMark(screen *ebiten.Image, fromX, fromY, x, y float64) {
+	pulse := 0.5 + 0.5*math.Sin(float64(time.Now().UnixMilli())/250.0)
+	lineCol := color.NRGBA{255, 90, 60, uint8(40 + 30*pulse)}
+	vector.StrokeLine(screen, float32(fromX), float32(fromY), float32(x), float32(y), 2, lineCol, true)
+	ringCol := color.NRGBA{255, 90, 60, uint8(110 + 90*pulse)}
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(tileSize)*0.7+float32(2*pulse), 2, ringCol, true)
+}
+
+// drawOverwatch рисует прицел в левом нижнем углу игрока, который стоит в дозоре
+func drawOverwatch(screen *ebiten.Image, x, y float64) {
+	sx, sy := float32(x-tileSize/2), float32(y+tileSize/2)
+	vector.DrawFilledCircle(screen, sx, sy, 10, color.RGBA{60, 40, 10, 255}, true)
+	amber := color.RGBA{250, 190, 60, 255}
+	vector.StrokeCircle(screen, sx, sy, 5, 1.5, amber, true)
+	vector.StrokeLine(screen, sx-8, sy, sx+8, sy, 1.5, amber, true)
+	vector.StrokeLine(screen, sx, sy-8, sx, sy+8, 1.5, amber, true)
+}
+
+// drawShield рисует щит в левом верхнем углу игрока, который стоит в защите
+func drawShield(screen *ebiten.Image, x, y float64) {
+	sx, sy := float32(x-tileSize/2), float32(y-tileSize/2)
+	vector.DrawFilledCircle(screen, sx, sy, 10, color.RGBA{20, 30, 60, 255}, true)
+	// верх щита – прямоугольник, низ – скруглённый
+	blue := color.RGBA{110, 160, 240, 255}
+	vector.DrawFilledRect(screen, sx-6, sy-7, 12, 6, blue, false)
+	vector.DrawFilledCircle(screen, sx, sy-1, 6, blue, true)
+	vector.StrokeLine(screen, sx, sy-6, sx, sy+4, 1.5, color.White, true)
+}
+
+// drawHPBar рисует полоску здоровья над игроком; цвет меняется от зелёного к красному
+func (g *Game) drawHPBar(screen *ebiten.Image, x, y, hp float64) {
+	const (
+		barW = float32(tileSize + 8)
+		barH = 5
+	)
+	ratio := hp / playerMaxHP
+	if ratio < 0 {
+		ratio = 0
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	left := float32(x) - barW/2
+	top := float32(y) - float32(tileSize)/2 - 10
+
+	fill := color.RGBA{uint8(255 * (1 - ratio)), uint8(200 * ratio), 40, 255}
+	vector.DrawFilledRect(screen, left-1, top-1, barW+2, barH+2, color.RGBA{0, 0, 0, 180}, false)
+	vector.DrawFilledRect(screen, left, top, barW*float32(ratio), barH, fill, false)
+}
+
+// drawConnectionLight рисует индикатор связи в правом верхнем углу:
+// зелёный – обновления приходят, жёлтый – задержка больше секунды, красный – связь потеряна
+func (g *Game) drawConnectionLight(screen *ebiten.Image, stateAge time.Duration, lost bool) {
+	const (
+		radius = 8
+		lightY = 20
+	)
+	lightX := float32(g.hudX(screenW-20, 0))
+	col := color.RGBA{60, 200, 60, 255}
+	switch {
+	case lost:
+		col = color.RGBA{220, 40, 40, 255}
+	case stateAge > time.Second:
+		col = color.RGBA{230, 200, 40, 255}
+	}
+	vector.DrawFilledCircle(screen, lightX, lightY, radius+2, color.RGBA{0, 0, 0, 150}, true)
+	vector.DrawFilledCircle(screen, lightX, lightY, radius, col, true)
+}
+
+// hudX возвращает левый край боковой панели шириной w, которая обычно стоит в x.
+// В интерфейсе для левши панель отражается относительно центра экрана
+func (g *Game) hudX(x, w float64) float64 {
+	if g.leftHanded {
+		return screenW - x - w
+	}
+	return x
+}
+
+// drawDamageIndicator рисует у края экрана красную стрелку в сторону атакующего, затухающую за damageIndicatorSeconds
+func (g *Game) drawDamageIndicator(screen *ebiten.Image, d DamageIndicator) {
+	alpha := 1 - time.Since(d.Start).Seconds()/damageIndicatorSeconds
+	if alpha <= 0 {
+		return
+	}
+
+	// точка на эллипсе, вписанном в экран с отступом
+	cos, sin := math.Cos(d.Angle), math.Sin(d.Angle)
+	rx := float64(screenW)/2 - damageIndicatorMargin
+	ry := float64(screenH)/2 - damageIndicatorMargin
+	tipX := screenW/2 + cos*rx
+	tipY := screenH/2 + sin*ry
+
+	const length, halfWidth = 40.0, 22.0
+	baseX := tipX - cos*length
+	baseY := tipY - sin*length
+	col := color.RGBA{R: uint8(220 * alpha), A: uint8(220 * alpha)}
+	g.fillTriangle(screen,
+		tipX, tipY,
+		baseX-sin*halfWidth, baseY+cos*halfWidth,
+		baseX+sin*halfWidth, baseY-cos*halfWidth,
+		col)
+}
+
+// drawLowHPVignette рисует пульсирующую красную виньетку, тем ярче, чем меньше HP
+func (g *Game) drawLowHPVignette(screen *ebiten.Image, hp int) {
+	if g.lowHPVignette == nil {
+		return
+	}
+	intensity := float64(lowHPThreshold-hp+1) / float64(lowHPThreshold)
+	// чем меньше здоровья, тем чаще пульс
+	rate := 1.0 + intensity
+	t := float64(time.Now().UnixMilli()) / 1000.0
+	pulse := 0.5 + 0.5*math.Sin(t*rate*2*math.Pi)
+	alpha := intensity * (0.6 + 0.4*pulse)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(vignetteDownscale, vignetteDownscale)
+	op.ColorScale.ScaleAlpha(float32(alpha))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(g.lowHPVignette, op)
+}
+
+// drawRejectedTile рисует над клеткой отклонённого клика красный крест, который
+// дрожит и гаснет за rejectSeconds
+func drawRejectedTile(screen *ebiten.Image, tileX, tileY int, at time.Time, camX, camY float64) {
+	if at.IsZero() {
+		return
+	}
+	elapsed := time.Since(at).Seconds()
+	if elapsed >= rejectSeconds {
+		return
+	}
+	fade := 1 - elapsed/rejectSeconds
+	shake := math.Sin(elapsed*60) * rejectShakeWidth * fade
+
+	const arm = tileSize / 3
+	sx := float32(float64(tileX*tileSize+tileSize/2) - camX + shake)
+	sy := float32(float64(tileY*tileSize+tileSize/2) - camY)
+	c := color.NRGBA{230, 40, 40, uint8(230 * fade)}
+	vector.StrokeLine(screen, sx-arm, sy-arm, sx+arm, sy+arm, 4, c, true)
+	vector.StrokeLine(screen, sx-arm, sy+arm, sx+arm, sy-arm, 4, c, true)
+}
+
+// drawToast рисует всплывающую подсказку по центру над полем, затухающую к концу показа
+func (g *Game) drawToast(screen *ebiten.Image, msg string, shownAt time.Time) {
+	elapsed := time.Since(shownAt).Seconds()
+	if elapsed >= toastSeconds {
+		return
+	}
+	alpha := min(1, 3*(1-elapsed/toastSeconds))
+	bounds := text.BoundString(g.fontFace, msg)
+	x := (screenW - bounds.Dx()) / 2
+	y := screenH/2 - 120
+	vector.DrawFilledRect(screen, float32(x-20), float32(y-bounds.Dy()-14), float32(bounds.Dx()+40), float32(bounds.Dy()+28),
+		color.NRGBA{0, 0, 0, uint8(160 * alpha)}, false)
+	text.Draw(screen, msg, g.fontFace, x, y, color.NRGBA{255, 220, 120, uint8(255 * alpha)})
+}
+
+// drawAnnounce показывает объявление сервера крупно в верхней части поля и плавно гасит его
+func (g *Game) drawAnnounce(screen *ebiten.Image, msg string, shownAt time.Time) {
+	elapsed := time.Since(shownAt).Seconds()
+	if elapsed >= announceSeconds {
+		return
+	}
+	alpha := min(1, 3*(1-elapsed/announceSeconds))
+	bounds := text.BoundString(g.logoFontFace, msg)
+	x := (screenW - bounds.Dx()) / 2
+	y := screenH / 4
+	text.Draw(screen, msg, g.logoFontFace, x+3, y+3, color.NRGBA{0, 0, 0, uint8(200 * alpha)})
+	text.Draw(screen, msg, g.logoFontFace, x, y, color.NRGBA{255, 200, 60, uint8(255 * alpha)})
+}
+
+// drawActionMode показывает над таймером хода режим действия, выбранный колесом мыши
+func (g *Game) drawActionMode(screen *ebiten.Image, mode int) {
+	label := fmt.Sprintf(tr("action.label"), tr(actionModeNames[mode]))
+	bounds := text.BoundString(g.chatFontFace, label)
+	x := int(g.hudX(screenW-480, float64(bounds.Dx()+20)))
+	y := screenH - 230
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(bounds.Dx()+20), 32, color.RGBA{0, 0, 0, 150}, false)
+	col := color.RGBA{255, 255, 255, 255}
+	if mode != actionAuto {
+		col = color.RGBA{255, 255, 0, 255}
+	}
+	text.Draw(screen, label, g.chatFontFace, x+10, y+23, col)
+}
+
+// drawTurnOrder выводит очередь ходов в правом верхнем углу. Текущий игрок подсвечен,
+// у переподключающихся крутится индикатор и стоит пометка – их ходы сервер пропускает
+func (g *Game) drawTurnOrder(screen *ebiten.Image, order []string, players map[string]*Player, current string) {
+	if len(order) == 0 {
+		return
+	}
+	const (
+		panelW = 330
+		rowH   = 28
+	)
+	x := float32(g.hudX(screenW-panelW-40, panelW))
+	y := float32(14)
+	vector.DrawFilledRect(screen, x, y, panelW, float32(len(order)*rowH+10), color.RGBA{0, 0, 0, 130}, false)
+
+	now := float64(time.Now().UnixMilli())
+	for i, id := range order {
+		pl, ok := players[id]
+		if !ok {
+			continue
+		}
+		rowY := y + 5 + float32(i*rowH)
+		if id == current {
+			vector.DrawFilledRect(screen, x, rowY, panelW, rowH, color.RGBA{120, 100, 20, 160}, false)
+		}
+		vector.DrawFilledRect(screen, x+8, rowY+7, 14, 14, color.RGBA{pl.Color.R, pl.Color.G, pl.Color.B, 255}, false)
+
+		label := pl.Name
+		textCol := color.Color(color.White)
+		if pl.Reconnect {
+			label += " " + tr("turn.reconnecting")
+			textCol = color.RGBA{170, 170, 170, 255}
+
+			// спиннер: восемь точек, яркость бежит по кругу
+			cx, cy := x+panelW-18, rowY+rowH/2
+			for k := 0; k < 8; k++ {
+				a := float64(k) * math.Pi / 4
+				alpha := math.Mod(float64(k)/8+now/1000, 1)
+				vector.DrawFilledCircle(screen, cx+float32(7*math.Cos(a)), cy+float32(7*math.Sin(a)), 2,
+					color.NRGBA{255, 255, 255, uint8(60 + 195*alpha)}, true)
+			}
+		}
+		text.Draw(screen, label, g.chatFontFace, int(x)+30, int(rowY)+21, textCol)
 	}
 }
 
 // drawTurnTimer отрисовывает индикатор хода и таймер
-func (g *Game) drawTurnTimer(screen *ebiten.Image, timeLeft float64, myTurn bool, currentPlayerName string) {
+func (g *Game) drawTurnTimer(screen *ebiten.Image, timeLeft float64, myTurn, waiting bool, currentPlayerName string) {
 	const (
-		timerX = screenW - 150
 		timerY = screenH - 150
 		width  = 150.0
 		height = 120.0
@@ -2239,16 +5485,19 @@ func (g *Game) drawTurnTimer(screen *ebiten.Image, timeLeft float64, myTurn bool
 		return
 	}
 
-	textX := timerX - 330
-	textY := timerY - 40
 	textW := 300.0
+	timerX := g.hudX(screenW-width, width)
+	textX := g.hudX(screenW-width-330, textW)
+	textY := timerY - 40
 	textH := 170.0
 	vector.DrawFilledRect(screen, float32(textX), float32(textY), float32(textW), float32(textH), color.RGBA{0, 0, 0, 150}, false)
 
-	if myTurn {
-		text.Draw(screen, "ВАШ ХОД", g.fontFace, int(textX)+10, int(textY)+40, color.RGBA{255, 255, 0, 255})
+	if waiting {
+		text.Draw(screen, tr("turn.waiting"), g.fontFace, int(textX)+10, int(textY)+40, color.RGBA{180, 200, 255, 255})
+	} else if myTurn {
+		text.Draw(screen, tr("turn.yours"), g.fontFace, int(textX)+10, int(textY)+40, color.RGBA{255, 255, 0, 255})
 	} else {
-		text.Draw(screen, "Ход игрока", g.fontFace, int(textX)+10, int(textY)+40, color.White)
+		text.Draw(screen, tr("turn.other"), g.fontFace, int(textX)+10, int(textY)+40, color.White)
 		text.Draw(screen, currentPlayerName, g.fontFace, int(textX)+10, int(textY)+85, color.White)
 	}
 	timeStr := fmt.Sprintf("%.1f", timeLeft)
@@ -2358,22 +5607,26 @@ func (g *Game) fillTriangle(screen *ebiten.Image, x1, y1, x2, y2, x3, y3 float64
 // drawChat отрисовывает чат
 func (g *Game) drawChat(screen *ebiten.Image, chatHistory []ChatMessage, chatOpen bool, chatBuffer string, chatCursor bool, _ time.Time, chatCursorTimer time.Time) {
 	const (
-		chatWidth    = 500
 		margin       = 10
-		lineHeight   = 22
 		textLeftPad  = 10
 		textRightPad = 10
 	)
 
-	chatHeight := chatHeightFixed
+	chatWidth, chatHeight, lineHeight := g.chatWidth, g.chatHeight, g.chatLineHeight
+	panelX := margin
+	if g.chatCorner == "right" {
+		panelX = screenW - chatWidth - margin
+	}
+	panelX = int(g.hudX(float64(panelX), float64(chatWidth)))
 
 	chatBg := ebiten.NewImage(chatWidth, chatHeight)
 	chatBg.Fill(color.RGBA{0, 0, 0, 180})
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(margin), float64(screenH-chatHeight-margin))
+	op.GeoM.Translate(float64(panelX), float64(screenH-chatHeight-margin))
 	screen.DrawImage(chatBg, op)
 
 	type displayLine struct {
+		stamp     string
 		nick      string
 		nickColor color.Color
 		text      string
@@ -2381,9 +5634,21 @@ func (g *Game) drawChat(screen *ebiten.Image, chatHistory []ChatMessage, chatOpe
 	displayLines := []displayLine{}
 
 	for _, msg := range chatHistory {
+		// зрители видят свой канал всегда, живые игроки – если не скрыли его в настройках
+		if msg.Spectator && g.hideSpectators && !g.spectating {
+			continue
+		}
+		stamp := ""
+		if g.chatTimestamps && msg.Time > 0 {
+			stamp = time.UnixMilli(msg.Time).Format("15:04") + " "
+		}
 		nick := "[" + msg.From + "]: "
+		if msg.Spectator {
+			nick = tr("chat.spectator") + nick
+		}
 		nickColor := color.RGBA{msg.Color.R, msg.Color.G, msg.Color.B, 255}
-		textMaxWidth := chatWidth - textLeftPad - textRightPad - text.BoundString(g.chatFontFace, nick).Dx() - 5
+		prefixWidth := text.BoundString(g.chatFontFace, stamp+nick).Dx()
+		textMaxWidth := chatWidth - textLeftPad - textRightPad - prefixWidth - 5
 		textLines := wrapText(g.chatFontFace, msg.Text, textMaxWidth)
 		if len(textLines) == 0 {
 			textLines = []string{""}
@@ -2392,12 +5657,13 @@ func (g *Game) drawChat(screen *ebiten.Image, chatHistory []ChatMessage, chatOpe
 		for i, line := range textLines {
 			if i == 0 {
 				displayLines = append(displayLines, displayLine{
+					stamp:     stamp,
 					nick:      nick,
 					nickColor: nickColor,
 					text:      line,
 				})
 			} else {
-				indent := strings.Repeat(" ", len(nick)/2)
+				indent := strings.Repeat(" ", (len(stamp)+len(nick))/2)
 				displayLines = append(displayLines, displayLine{
 					nick:      "",
 					nickColor: nil,
@@ -2453,7 +5719,11 @@ func (g *Game) drawChat(screen *ebiten.Image, chatHistory []ChatMessage, chatOpe
 	yPos := screenH - chatHeight + 5
 	for i := startIdx; i < endIdx; i++ {
 		line := displayLines[i]
-		xPos := margin + textLeftPad
+		xPos := panelX + textLeftPad
+		if line.stamp != "" {
+			text.Draw(screen, line.stamp, g.chatFontFace, xPos, yPos, color.RGBA{150, 150, 150, 255})
+			xPos += text.BoundString(g.chatFontFace, line.stamp).Dx()
+		}
 		if line.nick != "" {
 			text.Draw(screen, line.nick, g.chatFontFace, xPos, yPos, line.nickColor)
 			xPos += text.BoundString(g.chatFontFace, line.nick).Dx()
@@ -2469,7 +5739,7 @@ func (g *Game) drawChat(screen *ebiten.Image, chatHistory []ChatMessage, chatOpe
 			inputLines = []string{"> "}
 		}
 		inputHeight := len(inputLines)*lineHeight + 10
-		inputX := margin + 5
+		inputX := panelX + 5
 		inputY := screenH - margin - inputHeight - 5
 
 		inputBg := ebiten.NewImage(chatWidth-10, inputHeight)
@@ -2513,7 +5783,7 @@ func (g *Game) drawQuitConfirm(screen *ebiten.Image) {
 	opDialog.GeoM.Translate(float64(g.quitConfirmRects.bg.Min.X), float64(g.quitConfirmRects.bg.Min.Y))
 	screen.DrawImage(dialogImg, opDialog)
 
-	title := "Сдаёшься?"
+	title := tr("quit.title")
 	titleBounds := text.BoundString(g.fontFace, title)
 	titleX := g.quitConfirmRects.bg.Min.X + (g.quitConfirmRects.bg.Dx()-titleBounds.Dx())/2
 	titleY := g.quitConfirmRects.bg.Min.Y + 50
@@ -2527,7 +5797,7 @@ func (g *Game) drawQuitConfirm(screen *ebiten.Image) {
 	opYes := &ebiten.DrawImageOptions{}
 	opYes.GeoM.Translate(float64(g.quitConfirmRects.yes.Min.X), float64(g.quitConfirmRects.yes.Min.Y))
 	screen.DrawImage(yesImg, opYes)
-	yesText := "Главное меню"
+	yesText := tr("quit.menu")
 	yesBounds := text.BoundString(g.fontFace, yesText)
 	yesX := g.quitConfirmRects.yes.Min.X + (g.quitConfirmRects.yes.Dx()-yesBounds.Dx())/2
 	yesY := g.quitConfirmRects.yes.Min.Y + (g.quitConfirmRects.yes.Dy()+yesBounds.Dy())/2
@@ -2538,7 +5808,7 @@ func (g *Game) drawQuitConfirm(screen *ebiten.Image) {
 	opNo := &ebiten.DrawImageOptions{}
 	opNo.GeoM.Translate(float64(g.quitConfirmRects.no.Min.X), float64(g.quitConfirmRects.no.Min.Y))
 	screen.DrawImage(noImg, opNo)
-	noText := "Нет"
+	noText := tr("quit.no")
 	noBounds := text.BoundString(g.fontFace, noText)
 	noX := g.quitConfirmRects.no.Min.X + (g.quitConfirmRects.no.Dx()-noBounds.Dx())/2
 	noY := g.quitConfirmRects.no.Min.Y + (g.quitConfirmRects.no.Dy()+noBounds.Dy())/2
@@ -2549,7 +5819,7 @@ func (g *Game) drawQuitConfirm(screen *ebiten.Image) {
 	opExit := &ebiten.DrawImageOptions{}
 	opExit.GeoM.Translate(float64(g.quitConfirmRects.exit.Min.X), float64(g.quitConfirmRects.exit.Min.Y))
 	screen.DrawImage(exitImg, opExit)
-	exitText := "Выйти"
+	exitText := tr("quit.exit")
 	exitBounds := text.BoundString(g.fontFace, exitText)
 	exitX := g.quitConfirmRects.exit.Min.X + (g.quitConfirmRects.exit.Dx()-exitBounds.Dx())/2
 	exitY := g.quitConfirmRects.exit.Min.Y + (g.quitConfirmRects.exit.Dy()+exitBounds.Dy())/2
@@ -2561,6 +5831,85 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 	return screenW, screenH
 }
 
+// loadFonts создаёт шрифты с размерами, умноженными на uiScale.
+// Вызывается при запуске и при смене масштаба в настройках
+func (g *Game) loadFonts() {
+	newFace := func(tt *opentype.Font, size float64) (font.Face, error) {
+		return opentype.NewFace(tt, &opentype.FaceOptions{
+			Size:    size * g.uiScale,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+	}
+
+	ttChat, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		log.Fatal("Ошибка загрузки шрифта чата:", err)
+	}
+	var ttMain *opentype.Font
+	if ttfData, err := os.ReadFile("medieval.ttf"); err == nil {
+		ttMain, _ = opentype.Parse(ttfData)
+	}
+	if ttMain == nil {
+		fmt.Println("Используется запасной шрифт")
+		ttMain = ttChat
+	}
+
+	if g.fontFace, err = newFace(ttMain, 32); err != nil {
+		log.Fatal("Ошибка создания шрифта:", err)
+	}
+	if g.chatFontFace, err = newFace(ttChat, 22); err != nil {
+		log.Fatal("Ошибка создания шрифта чата:", err)
+	}
+	if g.logoFontFace, err = newFace(ttMain, 96); err != nil {
+		log.Fatal("Ошибка создания шрифта логотипа:", err)
+	}
+	if g.nameFontFace, err = newFace(ttMain, 28); err != nil {
+		log.Fatal("Ошибка создания шрифта имён:", err)
+	}
+	g.chatLineHeight = int(math.Round(22 * g.uiScale))
+}
+
+// nextUIScale переключает масштаб интерфейса на следующий из uiScalePresets и пересоздаёт шрифты
+func (g *Game) nextUIScale() {
+	next := uiScalePresets[0]
+	for i, s := range uiScalePresets {
+		if s == g.uiScale {
+			next = uiScalePresets[(i+1)%len(uiScalePresets)]
+			break
+		}
+	}
+	g.uiScale = next
+	g.loadFonts()
+}
+
+// setFullscreen включает или выключает полноэкранный режим; при выходе из него
+// окно получает выбранный в настройках размер
+func (g *Game) setFullscreen(on bool) {
+	g.fullscreen = on
+	g.savedFullscreen = on
+	ebiten.SetFullscreen(on)
+	if !on {
+		ebiten.SetWindowSize(g.windowW, g.windowH)
+	}
+}
+
+// nextWindowSize переключает размер окна на следующий из windowSizePresets.
+// В полноэкранном режиме размер только запоминается до выхода из него
+func (g *Game) nextWindowSize() {
+	next := windowSizePresets[0]
+	for i, s := range windowSizePresets {
+		if s == [2]int{g.windowW, g.windowH} {
+			next = windowSizePresets[(i+1)%len(windowSizePresets)]
+			break
+		}
+	}
+	g.windowW, g.windowH = next[0], next[1]
+	if !g.fullscreen {
+		ebiten.SetWindowSize(g.windowW, g.windowH)
+	}
+}
+
 // disconnect закрывает соединение и сбрасывает состояние
 func (g *Game) disconnect() {
 	g.mu.Lock()
@@ -2572,118 +5921,57 @@ func (g *Game) disconnect() {
 	g.connected = false
 	g.ready = false
 	g.id = ""
+	g.connPhase = phaseConnecting
+	g.lastStateTime = time.Time{}
+	g.pendingState = nil
+	g.cursorKind = ""
+	g.myTurn = false
+	g.actedThisTurn = false
+	g.currentTurn = ""
 	g.players = make(map[string]*Player)
+	g.fadingPlayers = make(map[string]*Player)
 	g.myPlayer = nil
 	g.gameMap = nil
+	g.damageIndicators = nil
+	g.spectating = false
+	g.spectatorFollowID = ""
+	g.spectatorFreeCam = false
+	g.spectatorOverview = false
+	g.camZoom = 1
+	g.predicting = false
+	g.turnOrder = nil
 }
 
 // ==================== ТОЧКА ВХОДА ====================
 
 func main() {
-	fmt.Println("=== Клиент ===")
-
-	var fontFace font.Face
-	var err error
-
-	ttfData, err := os.ReadFile("medieval.ttf")
-	if err == nil {
-		tt, err := opentype.Parse(ttfData)
-		if err == nil {
-			fontFace, err = opentype.NewFace(tt, &opentype.FaceOptions{
-				Size:    32,
-				DPI:     72,
-				Hinting: font.HintingFull,
-			})
-		}
-	}
-	if fontFace == nil {
-		tt, err := opentype.Parse(goregular.TTF)
-		if err != nil {
-			log.Fatal("Ошибка загрузки запасного шрифта:", err)
-		}
-		fontFace, err = opentype.NewFace(tt, &opentype.FaceOptions{
-			Size:    32,
-			DPI:     72,
-			Hinting: font.HintingFull,
-		})
-		if err != nil {
-			log.Fatal("Ошибка создания запасного шрифта:", err)
-		}
-		fmt.Println("Используется запасной шрифт")
-	} else {
-		fmt.Println("Загружен шрифт medieval.ttf")
-	}
-
-	ttChat, err := opentype.Parse(goregular.TTF)
-	if err != nil {
-		log.Fatal("Ошибка загрузки шрифта чата:", err)
-	}
-	chatFontFace, err := opentype.NewFace(ttChat, &opentype.FaceOptions{
-		Size:    22,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
-	if err != nil {
-		log.Fatal("Ошибка создания шрифта чата:", err)
-	}
+	windowed := flag.Bool("windowed", false, "запуск в окне, независимо от сохранённой настройки")
+	uncapped := flag.Bool("uncapped", false, "для разработки: выключить VSync и не ограничивать частоту кадров")
+	flag.Parse()
 
-	var logoFontFace font.Face
-	if ttfData != nil {
-		tt, err := opentype.Parse(ttfData)
-		if err == nil {
-			logoFontFace, err = opentype.NewFace(tt, &opentype.FaceOptions{
-				Size:    96,
-				DPI:     72,
-				Hinting: font.HintingFull,
-			})
-		}
-	}
-	if logoFontFace == nil {
-		logoFontFace, _ = opentype.NewFace(ttChat, &opentype.FaceOptions{
-			Size:    96,
-			DPI:     72,
-			Hinting: font.HintingFull,
-		})
-	}
+	fmt.Println("=== Клиент ===")
 
-	var nameFontFace font.Face
-	if ttfData != nil {
-		tt, err := opentype.Parse(ttfData)
-		if err == nil {
-			nameFontFace, err = opentype.NewFace(tt, &opentype.FaceOptions{
-				Size:    28,
-				DPI:     72,
-				Hinting: font.HintingFull,
-			})
-		}
-	}
-	if nameFontFace == nil {
-		nameFontFace, _ = opentype.NewFace(ttChat, &opentype.FaceOptions{
-			Size:    28,
-			DPI:     72,
-			Hinting: font.HintingFull,
-		})
+	settings := loadSettings()
+	uiLang = settings.Language
+	if m := ebiten.Monitor(); m != nil {
+		fmt.Printf("Масштаб экрана: %.2f, масштаб интерфейса: %.2f\n", m.DeviceScaleFactor(), settings.UIScale)
 	}
 
 	fmt.Println("Создание объекта игры...")
 	game := &Game{
 		state:               "mainmenu",
 		players:             make(map[string]*Player),
+		fadingPlayers:       make(map[string]*Player),
 		ready:               false,
 		connected:           false,
 		tileCache:           make(map[int]*ebiten.Image),
 		lastMove:            time.Now(),
-		fontFace:            fontFace,
-		chatFontFace:        chatFontFace,
-		logoFontFace:        logoFontFace,
-		nameFontFace:        nameFontFace,
 		showDebug:           false,
 		connectionLost:      false,
 		chatHistory:         make([]ChatMessage, 0),
 		chatOpen:            false,
 		chatJustOpened:      false,
 		chatScrollOffset:    0,
-		chatLineHeight:      22,
 		chatUserScrolled:    false,
 		mySwordCurrentAngle: 0,
 		mySwordTargetAngle:  0,
@@ -2698,34 +5986,62 @@ func main() {
 		charConnecting:    false,
 		charNameEdit:      false,
 		charColorButtons:  make([]image.Rectangle, 20),
+		charPrevKeys:      make(map[ebiten.Key]bool),
+		charRaceBtns:      make([]image.Rectangle, len(races)),
 		colorsFetched:     false,
 
 		mainMenuMap:     generateMainMenuMap(),
 		mainMenuOffsetX: 0,
 		mainMenuOffsetY: 0,
 		mainMenuButtons: []MainMenuButton{
-			{Text: "Играть", Action: func(g *Game) {
+			{Text: "menu.play", Action: func(g *Game) {
+				serverAddr = defaultServerAddr
+				g.practice = false
 				g.charSelectedColor = -1
 				g.colorsFetched = false
 				g.charError = ""
 				g.charConnecting = false
 				g.state = "character"
 			}},
-			{Text: "Настройки", Action: func(g *Game) {
+			{Text: "menu.practice", Action: func(g *Game) { g.startPractice() }},
+			{Text: "menu.settings", Action: func(g *Game) {
 				g.state = "settings"
 			}},
-			{Text: "Выход", Action: func(g *Game) { os.Exit(0) }},
+			{Text: "menu.exit", Action: func(g *Game) { os.Exit(0) }},
 		},
-		mainMenuButtonRects: make([]image.Rectangle, 3),
 
-		glowImage: createGlowImage(36),
-
-		volume:     50,
-		fullscreen: true,
-	}
+		glowImage:     createGlowImage(36),
+		lowHPVignette: createVignetteImage(screenW/vignetteDownscale, screenH/vignetteDownscale),
+
+		volume:         settings.Volume,
+		fullscreen:     settings.Fullscreen && !*windowed,
+		confirmActions: settings.ConfirmActions,
+		showGrid:       settings.ShowGrid,
+		showSpawnZone:  settings.ShowSpawnZone,
+		chatWidth:      settings.ChatWidth,
+		chatHeight:     settings.ChatHeight,
+		chatCorner:     settings.ChatCorner,
+		uiScale:        settings.UIScale,
+		leftHanded:     settings.LeftHanded,
+		chatTimestamps: settings.ChatTimestamps,
+		hideSpectators: settings.HideSpectators,
+		coloredNames:   settings.ColoredNames,
+		perfMode:       settings.Performance,
+		windowW:        settings.WindowWidth,
+		windowH:        settings.WindowHeight,
+	}
+	game.savedFullscreen = settings.Fullscreen
+	game.camZoom = 1
+	game.loadFonts()
+
+	if _, ok := loadSession(); ok {
+		rejoin := MainMenuButton{Text: rejoinButtonText, Action: func(g *Game) { g.rejoinSession() }}
+		game.mainMenuButtons = append([]MainMenuButton{rejoin}, game.mainMenuButtons...)
+	}
+	game.mainMenuButtonRects = make([]image.Rectangle, len(game.mainMenuButtons))
 
 	// Инициализация аудио
-	audioContext := audio.NewContext(44100)
+	audioContext := audio.NewContext(sampleRate)
 	game.audioContext = audioContext
 
 	if player, err := game.loadMusic("menu.ogg"); err == nil {
@@ -2738,6 +6054,11 @@ func main() {
 	} else {
 		log.Println("Не удалось загрузить game.ogg:", err)
 	}
+	if game.heartbeat = newHeartbeat(audioContext); game.heartbeat != nil {
+		game.heartbeat.SetVolume(float64(game.volume) / 100.0 * 0.5)
+	}
+	game.turnChime = newChime(audioContext)
+	game.turnChime.SetVolume(float64(game.volume) / 100.0)
 
 	game.quitConfirmRects.bg = image.Rect(0, 0, 600, 250)
 	game.quitConfirmRects.yes = image.Rect(0, 0, 250, 40)
@@ -2750,11 +6071,11 @@ func main() {
 	fmt.Println("Инициализация кэша тайлов...")
 	game.initTileCache()
 
-	ebiten.SetWindowSize(screenW, screenH)
-	ebiten.SetWindowTitle("RPG")
+	ebiten.SetWindowSize(game.windowW, game.windowH)
+	ebiten.SetWindowTitle(windowTitle)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetFullscreen(game.fullscreen)
-	ebiten.SetTPS(60)
+	game.setUncapped(*uncapped)
 	ebiten.SetWindowClosingHandled(true)
 
 	fmt.Println("Запуск ebiten...")
@@ -2766,4 +6087,4 @@ func main() {
 		}
 		log.Fatal("Ошибка запуска игры:", err)
 	}
-}
\ No newline at end of file
+}
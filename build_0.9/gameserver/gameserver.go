@@ -0,0 +1,2876 @@
+// Package gameserver – игровой сервер cats&slaps. Запускается отдельной программой
+// (build_0.9/server) или прямо внутри клиента для режима тренировки.
+package gameserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ==================== КОНСТАНТЫ ====================
+
+const (
+	mapW        = 150              // ширина карты в тайлах
+	mapH        = 150              // высота карты
+	tileSize    = 32               // размер тайла в пикселях
+	maxPlayers  = 10               // максимальное количество игроков на сервере
+	turnTimeout = 20 * time.Second // длительность хода
+	spawnRadius = 2                // полуширина безопасной зоны в центре (зона 5x5)
+	maxHP       = 10               // максимальное здоровье игрока
+	healAmount  = 2                // сколько здоровья восстанавливает лечение за ход
+	maxPoison   = 3                // предел наложений яда
+
+	maxMessageSize = 4096 // предел размера входящего сообщения WebSocket (байт); больше – разрыв соединения
+
+	chatLogFlushEvery = 5 * time.Second // как часто дописывать накопленный чат в журнал
+	chatLogMaxSize    = 1 << 20         // размер журнала чата, после которого он уходит в <файл>.1
+)
+
+// ==================== СТРУКТУРЫ ====================
+
+// Color – цвет в формате RGBA (для JSON)
+type Color struct {
+	R uint8 `json:"r"`
+	G uint8 `json:"g"`
+	B uint8 `json:"b"`
+	A uint8 `json:"a"`
+}
+
+// Player – данные игрока на сервере
+type Player struct {
+	ID        string    `json:"id"`     // уникальный идентификатор
+	Name      string    `json:"name"`   // имя
+	Race      string    `json:"race"`   // раса ("human" / "cat" / "dog" / "bird")
+	Weapon    string    `json:"weapon"` // оружие ("sword" / "spear")
+	X         float64   `json:"x"`      // текущая позиция X
+	Y         float64   `json:"y"`      // текущая позиция Y
+	TargetX   float64   `json:"tx"`     // целевая позиция X (для клиента)
+	TargetY   float64   `json:"ty"`     // целевая позиция Y (для клиента)
+	HP        int       `json:"hp"`     // здоровье
+	Color     Color     `json:"color"`  // цвет игрока
+	Dead      bool      `json:"-"`      // мёртв ли
+	DeathTime time.Time `json:"-"`      // время смерти
+	Poison    Poison    `json:"poison"` // отравление
+	Defending bool      `json:"-"`      // защищается: получает вдвое меньше урона до начала своего хода
+	Overwatch bool      `json:"-"`      // в дозоре: ударит первого врага, шагнувшего в зону удара
+	StanceOn  time.Time `json:"-"`      // начало хода, в котором игрок встал в защиту или дозор
+
+	Reconnecting bool `json:"-"` // соединение потеряно, место в очереди ждёт возвращения
+}
+
+// Poison – отравление: в начале каждого своего хода игрок теряет Stacks HP, пока не кончатся Turns
+type Poison struct {
+	Stacks int    `json:"stacks"` // сила яда (урон за ход)
+	Turns  int    `json:"turns"`  // сколько ходов ещё действует
+	From   string `json:"from"`   // кто отравил (ему засчитывается убийство)
+}
+
+// ChatMessage – сообщение чата
+type ChatMessage struct {
+	From  string `json:"from"`  // отправитель
+	Text  string `json:"text"`  // текст
+	Time  int64  `json:"time"`  // временная метка (мс)
+	Color Color  `json:"color"` // цвет отправителя
+
+	Spectator bool `json:"spectator,omitempty"` // написано зрителем (погибшим игроком)
+}
+
+// WeaponStats – боевые параметры оружия
+type WeaponStats struct {
+	Damage int      // урон за удар
+	Shape  [][2]int // смещения в клетках (dx, dy), по которым оружие достаёт
+}
+
+// Range – дальность оружия: самое дальнее смещение формы по любой из осей, в клетках
+func (w WeaponStats) Range() int {
+	r := 0
+	for _, off := range w.Shape {
+		r = max(r, abs(off[0]), abs(off[1]))
+	}
+	return r
+}
+
+// Snapshot – снимок состояния сервера для восстановления после перезапуска
+type Snapshot struct {
+	SavedAt     int64                  `json:"saved_at"`     // время снимка (мс)
+	Map         [][]int                `json:"map"`          // карта
+	Players     []Player               `json:"players"`      // живые игроки на момент снимка
+	TurnOrder   []string               `json:"turn_order"`   // очередь ходов (ID)
+	CurrentTurn int                    `json:"current_turn"` // индекс текущего хода
+	Chat        []ChatMessage          `json:"chat"`         // история чата
+	MatchStats  map[string]*MatchStats `json:"match_stats"`  // статистика текущего матча
+}
+
+// MatchStats – статистика игрока за текущий матч
+type MatchStats struct {
+	Name   string `json:"name"`   // имя игрока
+	Kills  int    `json:"kills"`  // убийства
+	Deaths int    `json:"deaths"` // смерти
+
+	Streak int `json:"-"` // убийств подряд без смерти
+}
+
+// Connection – обёртка над websocket-соединением с мьютексом
+type Connection struct {
+	conn   *websocket.Conn
+	mu     sync.Mutex
+	closed bool
+	ip     string // адрес клиента (для бана по IP)
+}
+
+// BanList – список банов, хранится в banFile
+type BanList struct {
+	Names []string `json:"names"` // имена в нижнем регистре
+	IPs   []string `json:"ips"`
+}
+
+// ServerStats – статистика сервера. Connections меняется под mu,
+// счётчики и замеры рассылки – под statsMu
+type ServerStats struct {
+	Players      int       // количество игроков
+	Connections  int       // количество соединений
+	LastUpdate   time.Time // время последнего обновления
+	MessagesSent int64     // всего отправлено сообщений
+	StartTime    time.Time // время запуска сервера
+	ChatMessages int64     // количество сообщений чата
+
+	BroadcastTime time.Duration // сколько занял последний сбор и маршалинг состояния
+	BroadcastMax  time.Duration // самый долгий сбор с прошлого вывода статистики
+}
+
+// StatsRecord – строка файла статистики (-stats-file)
+type StatsRecord struct {
+	Time         string `json:"time"`          // момент замера (RFC 3339)
+	Players      int    `json:"players"`       // игроков на сервере
+	Connections  int    `json:"connections"`   // подключений с запуска
+	MessagesSent int64  `json:"messages_sent"` // отправлено сообщений
+	ChatMessages int64  `json:"chat_messages"` // сообщений чата
+	UptimeSec    int64  `json:"uptime_sec"`    // аптайм в секундах
+}
+
+// ==================== ГЛОБАЛЬНЫЕ ПЕРЕМЕННЫЕ ====================
+
+var (
+	upgrader = websocket.Upgrader{
+		CheckOrigin:     func(r *http.Request) bool { return true },
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+
+	players     = make(map[string]*Player) // ID -> Player
+	playerNames = make(map[string]string)  // Name -> ID
+	conns       = make(map[string]*Connection)
+	gameMap     [][]int      // карта (типы тайлов)
+	mu          sync.RWMutex // основной мьютекс
+	stats       ServerStats  // статистика
+	statsMu     sync.Mutex   // счётчики и замеры в stats (кроме Connections)
+	usedColors  = make(map[uint32]bool)
+	chatHistory []ChatMessage
+	chatMu      sync.RWMutex
+	chatReplay  int // сколько последних сообщений чата отправлять при входе
+
+	spectatorChatToPlayers bool                       // показывать живым игрокам сообщения зрителей
+	spectators             = make(map[string]*Player) // ID соединения -> погибший игрок, наблюдающий за матчем (под mu)
+
+	// Журнал чата на диске
+	chatLogFile    string        // куда дописывать чат; пустой – не вести журнал
+	chatLogPending []ChatMessage // сообщения, ещё не записанные в журнал
+	chatLogMu      sync.Mutex
+
+	statsFile string // куда дописывать статистику из statsLoop (.csv или JSON Lines); пустой – не писать
+
+	playersOrder  []string     // порядок ходов (ID игроков)
+	currentTurn   int          // индекс текущего игрока в playersOrder
+	turnStartTime time.Time    // время начала текущего хода
+	turnMu        sync.RWMutex // мьютекс для пошагового режима
+	turnPaused    bool         // таймер хода стоит: живых игроков в очереди меньше двух
+
+	// Матч начинается, когда в очереди ходов оказываются хотя бы два живых игрока,
+	// и заканчивается, когда в живых остаётся один
+	matchStarted   bool
+	matchStartTime time.Time
+	matchStats     = make(map[string]*MatchStats) // ID -> статистика за матч
+	restoredStats  map[string]*MatchStats         // имя -> статистика из снимка, ещё не вернувшаяся к игроку
+	matchMu        sync.Mutex
+	killAnnounce   bool // объявлять первую кровь и серии убийств
+
+	// Каноничные формы атаки: меч бьёт только соседние по стороне клетки,
+	// копьё колет по прямой на 1–2 клетки, но не по диагонали
+	weaponStats = map[string]WeaponStats{
+		"sword": {Damage: 4, Shape: [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}},
+		"spear": {Damage: 2, Shape: [][2]int{
+			{1, 0}, {-1, 0}, {0, 1}, {0, -1},
+			{2, 0}, {-2, 0}, {0, 2}, {0, -2},
+		}},
+	}
+
+	// допустимые расы (должны совпадать с таблицей races на клиенте)
+	validRaces = map[string]bool{"human": true, "cat": true, "dog": true, "bird": true}
+
+	adminToken string // токен для административных эндпоинтов; пустой – они отключены
+
+	banFile     string                  // файл со списком банов
+	bannedNames = make(map[string]bool) // имя в нижнем регистре -> забанено
+	bannedIPs   = make(map[string]bool)
+	banMu       sync.RWMutex
+
+	// Снимки состояния
+	snapshotFile     string        // куда сохранять снимок
+	snapshotInterval time.Duration // период сохранения; 0 – не сохранять
+	restore          bool          // загрузить снимок при запуске
+	snapshotMu       sync.Mutex    // не даёт двум сохранениям писать файл одновременно
+
+	reconnectGrace time.Duration // сколько ждать переподключения живого игрока; 0 – не ждать
+
+	started atomic.Bool // StartServer уже вызывался
+
+	// Яд
+	poisonWeapon   string    // оружие, которое отравляет цель; пустое – яд выключен
+	poisonTurns    int       // сколько ходов действует отравление
+	poisonTickedAt time.Time // начало хода, для которого яд уже сработал (под mu)
+
+	unstickCheckedAt time.Time // начало хода, для которого застревание уже проверено (под mu)
+	stanceCheckedAt  time.Time // начало хода, для которого защита и дозор уже сняты (под mu)
+
+	// Правила атаки по диагонали
+	diagonalMelee bool // меч достаёт и до диагональных соседей
+	blockCorners  bool // диагональный удар не проходит, если обе клетки между бойцами непроходимы
+
+	// Автоматический перезапуск матча
+	autoRestart    bool          // начинать новый матч после окончания предыдущего
+	intermission   time.Duration // пауза между матчами
+	regenOnRestart bool          // генерировать новую карту для каждого матча
+	respawnAt      string        // где возвращать погибших: "random" – безопасная зона, "death" – у места гибели
+
+	// Область видимости: каждому клиенту отправляются только игроки рядом с ним
+	viewRadius int  // радиус в клетках; 0 – все видят всех
+	viewLOS    bool // дополнительно требовать прямую видимость (камни её закрывают)
+)
+
+// ==================== ОСНОВНАЯ ФУНКЦИЯ ====================
+
+// RegisterFlags объявляет настройки сервера в наборе флагов fs и выставляет им значения
+// по умолчанию. Вызывается до StartServer, даже если флаги не разбираются
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&adminToken, "admin-token", "", "токен для административных эндпоинтов (/reload, /ban, /unban); пустой – отключены")
+	fs.StringVar(&banFile, "ban-file", "bans.json", "файл со списком забаненных имён и IP (пусто – не хранить)")
+	fs.BoolVar(&autoRestart, "auto-restart", false, "автоматически начинать новый матч после окончания")
+	fs.DurationVar(&intermission, "intermission", 10*time.Second, "пауза между матчами при -auto-restart")
+	fs.BoolVar(&regenOnRestart, "regen-map", true, "генерировать новую карту при автоматическом перезапуске")
+	fs.StringVar(&respawnAt, "respawn-at", "random", "где возвращать погибших: random (безопасная зона) или death (у места гибели)")
+	fs.BoolVar(&diagonalMelee, "diagonal-melee", false, "меч бьёт по диагонали")
+	fs.BoolVar(&blockCorners, "block-corners", true, "запретить диагональный удар через угол препятствия")
+	fs.StringVar(&snapshotFile, "snapshot-file", "snapshot.json", "файл снимка состояния сервера")
+	fs.DurationVar(&snapshotInterval, "snapshot-interval", 30*time.Second, "период сохранения снимка (0 – отключить)")
+	fs.BoolVar(&restore, "restore", false, "восстановить карту, чат и статистику матча из снимка")
+	fs.StringVar(&poisonWeapon, "poison-weapon", "", "оружие, удар которого отравляет цель (sword/spear; пусто – без яда)")
+	fs.IntVar(&poisonTurns, "poison-turns", 3, "сколько ходов действует яд")
+	fs.DurationVar(&reconnectGrace, "reconnect-grace", 0, "сколько держать место игрока после потери соединения (0 – не держать)")
+	fs.StringVar(&chatLogFile, "chat-log", "", "файл журнала чата, например chatlog.txt (пусто – не вести)")
+	fs.BoolVar(&killAnnounce, "kill-announce", false, "объявлять первую кровь и серии из 3, 5, 7 и 10 убийств")
+	fs.StringVar(&statsFile, "stats-file", "", "файл для статистики каждые 10 с: .csv – CSV, иначе JSON Lines (пусто – не писать)")
+	fs.IntVar(&chatReplay, "chat-replay", 50, "сколько последних сообщений чата показывать вошедшему игроку")
+	fs.BoolVar(&spectatorChatToPlayers, "spectator-chat-to-players", false, "показывать живым игрокам чат зрителей (с пометкой [зритель])")
+	fs.IntVar(&viewRadius, "view-radius", 0, "присылать клиенту только игроков в этом радиусе, клеток (0 – всех)")
+	fs.BoolVar(&viewLOS, "view-los", true, "при -view-radius скрывать игроков за камнями")
+}
+
+// StartServer запускает сервер на адресе addr и обслуживает его до ошибки.
+// Состояние сервера глобальное, поэтому в одном процессе он запускается только один раз
+func StartServer(addr string) error {
+	if started.Swap(true) {
+		return errors.New("сервер уже запущен")
+	}
+	if _, ok := weaponStats[poisonWeapon]; poisonWeapon != "" && !ok {
+		return fmt.Errorf("неизвестное оружие для яда: %q", poisonWeapon)
+	}
+	switch respawnAt {
+	case "random", "death":
+	case "base":
+		return errors.New("-respawn-at=base недоступен: на сервере нет команд и баз")
+	default:
+		return fmt.Errorf("неизвестный режим -respawn-at: %q (random или death)", respawnAt)
+	}
+
+	if diagonalMelee {
+		weaponStats["sword"] = withDiagonals(weaponStats["sword"])
+	}
+
+	loadBans()
+
+	rand.Seed(time.Now().UnixNano())
+	stats.StartTime = time.Now()
+
+	fmt.Println("=== Сервер ===")
+	if !restore || !loadSnapshot() {
+		fmt.Println("Генерация карты...")
+		genMap()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", wsHandler)
+	mux.HandleFunc("/stats", statsHandler)
+	mux.HandleFunc("/colors", colorsHandler)
+	mux.HandleFunc("/player", playerHandler)
+	mux.HandleFunc("/reload", reloadHandler)
+	mux.HandleFunc("/ban", banHandler)
+	mux.HandleFunc("/unban", unbanHandler)
+
+	go broadcastLoop()
+	go statsLoop()
+	go cleanupLoop()
+	go turnTimeoutLoop()
+	if snapshotInterval > 0 {
+		go snapshotLoop()
+	}
+	if chatLogFile != "" {
+		go chatLogLoop()
+	}
+
+	host := addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+	fmt.Println("Сервер запущен на " + addr)
+	fmt.Println("WebSocket: ws://" + host + "/ws")
+	fmt.Println("Статистика: http://" + host + "/stats")
+	fmt.Println("Занятые цвета: http://" + host + "/colors")
+	fmt.Println("Игрок: http://" + host + "/player?name=...")
+	if adminToken != "" {
+		fmt.Println("Новая карта: POST http://" + host + "/reload (заголовок X-Admin-Token)")
+		fmt.Println("Бан: POST http://" + host + "/ban?name=...&ip=... (и /unban)")
+	}
+
+	return http.ListenAndServe(addr, mux)
+}
+
+// turnTimeoutLoop – проверка таймаута хода каждую секунду
+func turnTimeoutLoop() {
+	ticker := time.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		mu.RLock()
+		turnMu.Lock()
+		live := 0
+		for _, pid := range playersOrder {
+			if p, ok := players[pid]; ok && !p.Dead {
+				live++
+			}
+		}
+		changed := setTurnPaused(live < 2)
+		clampTurn()
+		if currentPlayerID := currentTurnID(); currentPlayerID != "" && !turnPaused {
+			currentPlayer := players[currentPlayerID]
+			if currentPlayer == nil {
+				// в очереди остался ID без игрока – ход застрял бы на нём навсегда
+				log.Printf("⚠️ Ход у неизвестного игрока %s – передаём следующему", currentPlayerID)
+				nextTurn()
+			} else if currentPlayer.Dead || currentPlayer.Reconnecting {
+				nextTurn()
+			} else if time.Since(turnStartTime) > turnTimeout {
+				log.Printf("⏰ Таймаут хода игрока %s", currentPlayerID)
+				nextTurn()
+			}
+		}
+		turnMu.Unlock()
+		mu.RUnlock()
+
+		if changed {
+			broadcastToAll()
+		}
+		if poisonWeapon != "" {
+			tickPoison()
+		}
+		unstickCurrent()
+		endStance()
+	}
+}
+
+// setTurnPaused ставит таймер хода на паузу, пока в очереди меньше двух живых игроков,
+// и запускает ход заново, когда соперник появился. Вызывать под turnMu.
+// Возвращает true, если состояние паузы изменилось
+func setTurnPaused(paused bool) bool {
+	if paused == turnPaused {
+		return false
+	}
+	turnPaused = paused
+	if paused {
+		log.Println("⏸ Таймер хода остановлен: ждём второго игрока")
+	} else {
+		turnStartTime = time.Now()
+		log.Println("▶️ Таймер хода запущен")
+	}
+	return true
+}
+
+// nextTurn – переход хода к следующему игроку
+func nextTurn() {
+	clampTurn()
+	if len(playersOrder) == 0 {
+		return
+	}
+	currentTurn = (currentTurn + 1) % len(playersOrder)
+	turnStartTime = time.Now()
+	log.Printf("➡️ Ход перешел к игроку %s", playersOrder[currentTurn])
+}
+
+// currentTurnID – ID игрока, чей сейчас ход; пустая строка, если очередь пуста.
+// Устаревший индекс не приводит к панике: берётся первый в очереди, как после clampTurn.
+// Вызывать под turnMu (достаточно RLock)
+func currentTurnID() string {
+	if len(playersOrder) == 0 {
+		return ""
+	}
+	if currentTurn < 0 || currentTurn >= len(playersOrder) {
+		return playersOrder[0]
+	}
+	return playersOrder[currentTurn]
+}
+
+// clampTurn возвращает currentTurn в пределы очереди, если после ошибки в удалении
+// он указывает мимо неё, и начинает ход заново. Вызывать под turnMu.Lock
+func clampTurn() {
+	if len(playersOrder) == 0 {
+		currentTurn = 0
+		return
+	}
+	if currentTurn < 0 || currentTurn >= len(playersOrder) {
+		log.Printf("⚠️ Индекс хода %d вне очереди из %d игроков – ход переходит к первому", currentTurn, len(playersOrder))
+		currentTurn = 0
+		turnStartTime = time.Now()
+	}
+}
+
+// generateRock – рекурсивная генерация камня
+func generateRock(gameMap [][]int, cx, cy, targetSize int) {
+	dirs := [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	cells := [][2]int{{cx, cy}}
+	gameMap[cy][cx] = 2
+
+	maxAttempts := targetSize * 10
+	attempts := 0
+
+	for len(cells) < targetSize && attempts < maxAttempts {
+		parent := cells[rand.Intn(len(cells))]
+		var neighbors [][2]int
+		for _, d := range dirs {
+			nx, ny := parent[0]+d[0], parent[1]+d[1]
+			if nx >= 0 && nx < mapW && ny >= 0 && ny < mapH && gameMap[ny][nx] == 0 {
+				neighbors = append(neighbors, [2]int{nx, ny})
+			}
+		}
+		if len(neighbors) > 0 {
+			newCell := neighbors[rand.Intn(len(neighbors))]
+			cells = append(cells, newCell)
+			gameMap[newCell[1]][newCell[0]] = 2
+			attempts = 0
+		} else {
+			attempts++
+		}
+	}
+}
+
+// colorsHandler – возвращает список занятых цветов
+func colorsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	mu.RLock()
+	colors := make([]Color, 0, len(usedColors))
+	for colKey := range usedColors {
+		c := Color{
+			R: uint8(colKey >> 24),
+			G: uint8(colKey >> 16),
+			B: uint8(colKey >> 8),
+			A: uint8(colKey),
+		}
+		colors = append(colors, c)
+	}
+	mu.RUnlock()
+
+	writeJSON(w, colors)
+}
+
+// PlayerInfo – публичное состояние игрока для /player
+type PlayerInfo struct {
+	ID     string  `json:"id"`     // идентификатор
+	Name   string  `json:"name"`   // имя
+	Race   string  `json:"race"`   // раса
+	Weapon string  `json:"weapon"` // оружие
+	X      float64 `json:"x"`      // позиция X
+	Y      float64 `json:"y"`      // позиция Y
+	HP     int     `json:"hp"`     // здоровье
+	Alive  bool    `json:"alive"`  // жив ли
+}
+
+// playerHandler – состояние одного игрока по имени (?name=) или идентификатору (?id=)
+func playerHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	id := r.URL.Query().Get("id")
+	if name == "" && id == "" {
+		http.Error(w, "name or id required", http.StatusBadRequest)
+		return
+	}
+
+	var info *PlayerInfo
+	mu.RLock()
+	for _, p := range players {
+		if (id != "" && p.ID == id) || (name != "" && strings.EqualFold(p.Name, name)) {
+			info = &PlayerInfo{
+				ID:     p.ID,
+				Name:   p.Name,
+				Race:   p.Race,
+				Weapon: p.Weapon,
+				X:      p.X,
+				Y:      p.Y,
+				HP:     p.HP,
+				Alive:  !p.Dead,
+			}
+			break
+		}
+	}
+	mu.RUnlock()
+
+	if info == nil {
+		http.Error(w, "player not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, info)
+}
+
+// allowGet выставляет CORS-заголовки и проверяет метод запроса.
+// Возвращает false, если ответ уже отправлен (preflight или неверный метод).
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+	switch r.Method {
+	case http.MethodGet:
+		return true
+	case http.MethodOptions:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, OPTIONS")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+	return false
+}
+
+// allowAdmin проверяет, что запрос – POST с верным токеном администратора.
+// Возвращает false, если ответ с ошибкой уже отправлен.
+func allowAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if adminToken == "" {
+		http.Error(w, "admin endpoints disabled", http.StatusForbidden)
+		return false
+	}
+	token := r.Header.Get("X-Admin-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token != adminToken {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// reloadHandler – генерирует новую карту, расставляет живых игроков по безопасным
+// клеткам, сбрасывает очередь ходов и рассылает карту и состояние всем
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowAdmin(w, r) {
+		return
+	}
+
+	count := resetMatch(true, false)
+	log.Printf("🔄 Карта перегенерирована, игроков на новых позициях: %d", count)
+	broadcastChat(ChatMessage{
+		From:  "Система",
+		Text:  "Начинается новый матч",
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	})
+
+	writeJSON(w, map[string]any{"ok": true, "players": count})
+}
+
+// resetMatch начинает матч заново: при regenerate создаёт новую карту, при revive
+// возвращает в игру погибших, но ещё подключённых игроков и восстанавливает всем HP.
+// Живые участники расставляются по безопасным клеткам, очередь ходов и статистика
+// сбрасываются, карта (если новая) и состояние рассылаются всем. Возвращает число участников.
+func resetMatch(regenerate, revive bool) int {
+	mu.Lock()
+	if regenerate {
+		genMap()
+	}
+	var participants []string
+	var occupied [][2]float64
+	for _, p := range players {
+		revived := p.Dead
+		if p.Dead {
+			_, connected := conns[p.ID]
+			owner, taken := playerNames[p.Name]
+			if !revive || !connected || (taken && owner != p.ID) {
+				continue
+			}
+			p.Dead = false
+			delete(spectators, p.ID)
+			playerNames[p.Name] = p.ID
+		}
+		if revive {
+			p.HP = maxHP
+			p.Poison = Poison{}
+		}
+		var x, y float64
+		if revived {
+			x, y = respawnPoint(p.X, p.Y, occupied)
+		} else {
+			x, y = pickSpawn(occupied)
+		}
+		p.X, p.Y = x, y
+		p.TargetX, p.TargetY = x, y
+		occupied = append(occupied, [2]float64{x, y})
+		participants = append(participants, p.ID)
+	}
+
+	// новая очередь: прежний порядок, вернувшиеся в игру – в конце
+	turnMu.Lock()
+	inOrder := make(map[string]bool, len(playersOrder))
+	order := make([]string, 0, len(participants))
+	for _, pid := range playersOrder {
+		if p, ok := players[pid]; ok && !p.Dead {
+			order = append(order, pid)
+			inOrder[pid] = true
+		}
+	}
+	for _, pid := range participants {
+		if !inOrder[pid] {
+			order = append(order, pid)
+		}
+	}
+	playersOrder = order
+	currentTurn = 0
+	turnStartTime = time.Now()
+	setTurnPaused(len(order) < 2)
+	turnMu.Unlock()
+
+	names := make(map[string]string, len(participants))
+	for _, id := range participants {
+		names[id] = players[id].Name
+	}
+	mapCopy := gameMap
+	mu.Unlock()
+
+	matchMu.Lock()
+	matchStats = make(map[string]*MatchStats)
+	restoredStats = nil
+	for id, name := range names {
+		matchStats[id] = &MatchStats{Name: name}
+	}
+	matchStarted = len(participants) >= 2
+	matchStartTime = time.Now()
+	matchMu.Unlock()
+
+	if regenerate {
+		broadcastMapChange(mapCopy)
+	}
+	broadcastToAll()
+	return len(participants)
+}
+
+// broadcastMapChange рассылает всем новую карту посреди сессии и объявляет об этом в чате.
+// Игроки к этому моменту уже должны стоять на допустимых клетках новой карты
+func broadcastMapChange(mapCopy [][]int) {
+	spawnMin, spawnMax := spawnBounds()
+	broadcastMessage(map[string]any{
+		"type":    "map",
+		"data":    mapCopy,
+		"spawn":   map[string]int{"min": spawnMin, "max": spawnMax},
+		"changed": true,
+	})
+	broadcastChat(ChatMessage{
+		From:  "Система",
+		Text:  "Карта изменилась",
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	})
+}
+
+// scheduleRestart – после окончания матча ждёт intermission и начинает новый
+func scheduleRestart() {
+	log.Printf("⏳ Новый матч через %v", intermission)
+	time.Sleep(intermission)
+
+	count := resetMatch(regenOnRestart, true)
+	log.Printf("🔁 Начался новый матч, участников: %d", count)
+	broadcastMessage(map[string]any{
+		"type":    "new_match",
+		"players": count,
+	})
+	broadcastChat(ChatMessage{
+		From:  "Система",
+		Text:  "Начинается новый матч!",
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	})
+}
+
+// remoteIP – адрес клиента без порта
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isBanned – забанено ли имя или IP (пустые значения не проверяются)
+func isBanned(name, ip string) bool {
+	banMu.RLock()
+	defer banMu.RUnlock()
+	return (name != "" && bannedNames[strings.ToLower(name)]) || (ip != "" && bannedIPs[ip])
+}
+
+// loadBans читает список банов из banFile (отсутствие файла – не ошибка)
+func loadBans() {
+	if banFile == "" {
+		return
+	}
+	data, err := os.ReadFile(banFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("Ошибка чтения списка банов:", err)
+		}
+		return
+	}
+	var list BanList
+	if err := json.Unmarshal(data, &list); err != nil {
+		log.Println("Ошибка разбора списка банов:", err)
+		return
+	}
+	banMu.Lock()
+	for _, n := range list.Names {
+		bannedNames[strings.ToLower(n)] = true
+	}
+	for _, ip := range list.IPs {
+		bannedIPs[ip] = true
+	}
+	banMu.Unlock()
+	log.Printf("Загружено банов: %d имён, %d IP", len(list.Names), len(list.IPs))
+}
+
+// snapshotLoop периодически сохраняет снимок состояния
+func snapshotLoop() {
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		saveSnapshot()
+	}
+}
+
+// chatLogLoop раз в chatLogFlushEvery дописывает новые сообщения чата в журнал,
+// чтобы запись на диск не шла в момент отправки сообщения
+func chatLogLoop() {
+	ticker := time.NewTicker(chatLogFlushEvery)
+	defer ticker.Stop()
+	for range ticker.C {
+		flushChatLog()
+	}
+}
+
+// flushChatLog забирает накопленные сообщения и дописывает их в chatLogFile строками
+// «время [отправитель]: текст». Переросший chatLogMaxSize журнал переименовывается в <файл>.1
+func flushChatLog() {
+	chatLogMu.Lock()
+	pending := chatLogPending
+	chatLogPending = nil
+	chatLogMu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	if info, err := os.Stat(chatLogFile); err == nil && info.Size() >= chatLogMaxSize {
+		if err := os.Rename(chatLogFile, chatLogFile+".1"); err != nil {
+			log.Println("Ошибка ротации журнала чата:", err)
+		}
+	}
+
+	f, err := os.OpenFile(chatLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println("Ошибка открытия журнала чата:", err)
+		return
+	}
+	w := bufio.NewWriter(f)
+	for _, msg := range pending {
+		stamp := time.UnixMilli(msg.Time).Format("2006-01-02 15:04:05")
+		// перевод строки в тексте сломал бы формат «одно сообщение – одна строка»
+		text := strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Text)
+		from := msg.From
+		if msg.Spectator {
+			from = "[зритель] " + from
+		}
+		fmt.Fprintf(w, "%s [%s]: %s\n", stamp, from, text)
+	}
+	if err := w.Flush(); err != nil {
+		log.Println("Ошибка записи журнала чата:", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Println("Ошибка записи журнала чата:", err)
+	}
+}
+
+// saveSnapshot собирает состояние под мьютексами и атомарно записывает его в snapshotFile:
+// сначала во временный файл рядом, затем переименованием, чтобы сбой не оставил полфайла
+func saveSnapshot() {
+	snap := Snapshot{SavedAt: time.Now().UnixMilli()}
+
+	mu.RLock()
+	snap.Map = make([][]int, len(gameMap))
+	for y, row := range gameMap {
+		snap.Map[y] = append([]int(nil), row...)
+	}
+	for _, p := range players {
+		if !p.Dead {
+			snap.Players = append(snap.Players, *p)
+		}
+	}
+	mu.RUnlock()
+
+	turnMu.RLock()
+	snap.TurnOrder = append([]string(nil), playersOrder...)
+	snap.CurrentTurn = currentTurn
+	turnMu.RUnlock()
+
+	chatMu.RLock()
+	snap.Chat = append([]ChatMessage(nil), chatHistory...)
+	chatMu.RUnlock()
+
+	matchMu.Lock()
+	snap.MatchStats = make(map[string]*MatchStats, len(matchStats))
+	for id, st := range matchStats {
+		cp := *st
+		snap.MatchStats[id] = &cp
+	}
+	// статистика из прошлого снимка, чьи владельцы ещё не вернулись, хранится под именем
+	for name, st := range restoredStats {
+		cp := *st
+		snap.MatchStats[name] = &cp
+	}
+	matchMu.Unlock()
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		log.Println("Ошибка сохранения снимка:", err)
+		return
+	}
+
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	tmp, err := os.CreateTemp(filepath.Dir(snapshotFile), filepath.Base(snapshotFile)+".tmp-*")
+	if err != nil {
+		log.Println("Ошибка сохранения снимка:", err)
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), snapshotFile)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		log.Println("Ошибка сохранения снимка:", err)
+	}
+}
+
+// loadSnapshot восстанавливает из snapshotFile карту, историю чата и статистику матча.
+// Игроки не восстанавливаются – им нужно переподключиться. Возвращает false, если снимка нет
+// или он повреждён, – тогда карта генерируется заново
+func loadSnapshot() bool {
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		log.Println("Снимок не загружен:", err)
+		return false
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		log.Println("Ошибка разбора снимка:", err)
+		return false
+	}
+	if len(snap.Map) != mapH {
+		log.Printf("Снимок не загружен: карта %d строк вместо %d", len(snap.Map), mapH)
+		return false
+	}
+	for _, row := range snap.Map {
+		if len(row) != mapW {
+			log.Printf("Снимок не загружен: строка карты длиной %d вместо %d", len(row), mapW)
+			return false
+		}
+	}
+
+	mu.Lock()
+	gameMap = snap.Map
+	mu.Unlock()
+
+	chatMu.Lock()
+	chatHistory = snap.Chat
+	chatMu.Unlock()
+
+	// ID игроков после перезапуска другие, поэтому статистика ждёт владельца по имени
+	// и переходит к нему при входе (wsHandler)
+	matchMu.Lock()
+	restoredStats = make(map[string]*MatchStats, len(snap.MatchStats))
+	for _, st := range snap.MatchStats {
+		if st != nil {
+			restoredStats[st.Name] = st
+		}
+	}
+	matchMu.Unlock()
+
+	log.Printf("💾 Восстановлен снимок от %s: игроков было %d, сообщений чата %d",
+		time.UnixMilli(snap.SavedAt).Format("2006-01-02 15:04:05"), len(snap.Players), len(snap.Chat))
+	return true
+}
+
+// saveBans записывает список банов в banFile (вызывать под banMu)
+func saveBans() {
+	if banFile == "" {
+		return
+	}
+	list := BanList{Names: []string{}, IPs: []string{}}
+	for n := range bannedNames {
+		list.Names = append(list.Names, n)
+	}
+	for ip := range bannedIPs {
+		list.IPs = append(list.IPs, ip)
+	}
+	sort.Strings(list.Names)
+	sort.Strings(list.IPs)
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		log.Println("Ошибка сохранения списка банов:", err)
+		return
+	}
+	if err := os.WriteFile(banFile, data, 0644); err != nil {
+		log.Println("Ошибка сохранения списка банов:", err)
+	}
+}
+
+// banHandler – банит имя и/или IP (параметры name, ip) и отключает подходящих игроков
+func banHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowAdmin(w, r) {
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	ip := strings.TrimSpace(r.FormValue("ip"))
+	if name == "" && ip == "" {
+		http.Error(w, "name or ip required", http.StatusBadRequest)
+		return
+	}
+
+	banMu.Lock()
+	if name != "" {
+		bannedNames[strings.ToLower(name)] = true
+	}
+	if ip != "" {
+		bannedIPs[ip] = true
+	}
+	saveBans()
+	banMu.Unlock()
+
+	// отключаем уже подключённых: соединение закрывается, очистка идёт в wsHandler
+	var kicked []*Connection
+	mu.RLock()
+	for id, conn := range conns {
+		p := players[id]
+		if (name != "" && p != nil && strings.EqualFold(p.Name, name)) || (ip != "" && conn.ip == ip) {
+			kicked = append(kicked, conn)
+		}
+	}
+	mu.RUnlock()
+
+	for _, conn := range kicked {
+		conn.mu.Lock()
+		if !conn.closed {
+			data, _ := json.Marshal(map[string]string{"error": "Вы забанены"})
+			conn.conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+			conn.conn.WriteMessage(websocket.TextMessage, data)
+			conn.closed = true
+			conn.conn.Close()
+		}
+		conn.mu.Unlock()
+	}
+
+	log.Printf("⛔ Бан: имя %q, IP %q, отключено соединений: %d", name, ip, len(kicked))
+	writeJSON(w, map[string]any{"ok": true, "kicked": len(kicked)})
+}
+
+// unbanHandler – снимает бан с имени и/или IP
+func unbanHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowAdmin(w, r) {
+		return
+	}
+	name := strings.TrimSpace(r.FormValue("name"))
+	ip := strings.TrimSpace(r.FormValue("ip"))
+	if name == "" && ip == "" {
+		http.Error(w, "name or ip required", http.StatusBadRequest)
+		return
+	}
+
+	banMu.Lock()
+	delete(bannedNames, strings.ToLower(name))
+	delete(bannedIPs, ip)
+	saveBans()
+	banMu.Unlock()
+
+	log.Printf("✅ Разбан: имя %q, IP %q", name, ip)
+	writeJSON(w, map[string]any{"ok": true})
+}
+
+// writeJSON кодирует data в JSON и отправляет ответ; при ошибке кодирования – 500
+func writeJSON(w http.ResponseWriter, data any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		log.Println("Ошибка кодирования JSON:", err)
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+// wsHandler – обработчик WebSocket-соединений
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	c, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println("Ошибка обновления до WebSocket:", err)
+		return
+	}
+	// Огромный кадр отклоняется до разбора JSON, а не после выделения памяти под него
+	c.SetReadLimit(maxMessageSize)
+
+	ip := remoteIP(r)
+	if isBanned("", ip) {
+		log.Printf("⛔ Отклонено подключение с забаненного адреса %s", ip)
+		c.WriteJSON(map[string]string{"error": "Вы забанены"})
+		c.Close()
+		return
+	}
+
+	// Читаем приветственное сообщение (имя, раса, оружие, цвет)
+	var hello map[string]interface{}
+	if err := c.ReadJSON(&hello); err != nil {
+		log.Println("Ошибка чтения приветствия:", err)
+		c.Close()
+		return
+	}
+
+	nameRaw, ok := hello["name"]
+	if !ok {
+		c.WriteJSON(map[string]string{"error": "Требуется имя"})
+		c.Close()
+		return
+	}
+	name, ok := nameRaw.(string)
+	if !ok {
+		c.WriteJSON(map[string]string{"error": "Имя должно быть строкой"})
+		c.Close()
+		return
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		c.WriteJSON(map[string]string{"error": "Имя не может быть пустым"})
+		c.Close()
+		return
+	}
+	if len(name) > 20 {
+		name = name[:20]
+	}
+	if isBanned(name, "") {
+		log.Printf("⛔ Отклонено подключение забаненного игрока %q (%s)", name, ip)
+		c.WriteJSON(map[string]string{"error": "Вы забанены"})
+		c.Close()
+		return
+	}
+
+	// Время последнего сообщения чата, которое клиент уже видел (при переподключении)
+	var chatSince int64
+	if s, ok := hello["since"].(float64); ok {
+		chatSince = int64(s)
+	}
+
+	// Игрок, потерявший соединение, возвращается на своё место
+	if p, ok := takeReconnecting(name, c, ip); ok {
+		servePlayer(c, p, true, chatSince)
+		return
+	}
+
+	race := "human"
+	if raceRaw, ok := hello["race"]; ok {
+		if r, ok := raceRaw.(string); ok && validRaces[r] {
+			race = r
+		}
+	}
+
+	weapon := "sword"
+	if weaponRaw, ok := hello["weapon"]; ok {
+		if w, ok := weaponRaw.(string); ok && (w == "sword" || w == "spear") {
+			weapon = w
+		}
+	}
+
+	var selectedColor *Color
+	if colorRaw, ok := hello["color"]; ok {
+		if colorMap, ok := colorRaw.(map[string]interface{}); ok {
+			var c Color
+			if r, ok := colorMap["r"].(float64); ok {
+				c.R = uint8(r)
+			}
+			if g, ok := colorMap["g"].(float64); ok {
+				c.G = uint8(g)
+			}
+			if b, ok := colorMap["b"].(float64); ok {
+				c.B = uint8(b)
+			}
+			if a, ok := colorMap["a"].(float64); ok {
+				c.A = uint8(a)
+			}
+			selectedColor = &c
+		}
+	}
+
+	mu.Lock()
+	// Проверяем, не занято ли имя
+	var deathX, deathY float64
+	respawning := false
+	if existingID, exists := playerNames[name]; exists {
+		if p, ok := players[existingID]; ok && p.Dead {
+			deathX, deathY, respawning = p.X, p.Y, true
+			delete(players, existingID)
+			delete(spectators, existingID)
+			delete(playerNames, name)
+			if conn, ok := conns[existingID]; ok {
+				conn.conn.Close()
+				delete(conns, existingID)
+			}
+		} else {
+			mu.Unlock()
+			c.WriteJSON(map[string]string{
+				"error": fmt.Sprintf("Имя '%s' уже занято", name),
+			})
+			c.Close()
+			return
+		}
+	}
+	if len(players) >= maxPlayers {
+		mu.Unlock()
+		c.WriteJSON(map[string]string{
+			"error": fmt.Sprintf("Сервер переполнен (максимум %d игроков)", maxPlayers),
+		})
+		c.Close()
+		return
+	}
+
+	// Выбор цвета: проверка и резервирование под тем же mu.Lock, что и проверка имени,
+	// чтобы два одновременных подключения не получили один цвет
+	var finalColor Color
+	if selectedColor != nil {
+		colorKey := colorToKey(*selectedColor)
+		if usedColors[colorKey] {
+			mu.Unlock()
+			c.WriteJSON(map[string]string{
+				"error": "Выбранный цвет уже занят",
+			})
+			c.Close()
+			return
+		}
+		finalColor = *selectedColor
+		usedColors[colorKey] = true
+	} else {
+		finalColor = reserveUniqueColor()
+	}
+	mu.Unlock()
+
+	// Поиск безопасного спавна; погибший под тем же именем возвращается по правилу -respawn-at
+	var x, y float64
+	if respawning {
+		x, y = findRespawn(deathX, deathY)
+	} else {
+		x, y = findSafeSpawn()
+	}
+	id := randID()
+
+	p := &Player{
+		ID:      id,
+		Name:    name,
+		Race:    race,
+		Weapon:  weapon,
+		X:       x,
+		Y:       y,
+		TargetX: x,
+		TargetY: y,
+		HP:      maxHP,
+		Color:   finalColor,
+		Dead:    false,
+	}
+
+	mu.Lock()
+	players[id] = p
+	playerNames[name] = id
+	conns[id] = &Connection{
+		conn:   c,
+		mu:     sync.Mutex{},
+		closed: false,
+		ip:     ip,
+	}
+	stats.Connections++
+	mu.Unlock()
+
+	// Добавляем в очередь ходов
+	turnMu.Lock()
+	playersOrder = append(playersOrder, id)
+	if len(playersOrder) == 1 {
+		currentTurn = 0
+		turnStartTime = time.Now()
+	}
+	orderLen := len(playersOrder)
+	setTurnPaused(orderLen < 2)
+	turnMu.Unlock()
+
+	matchMu.Lock()
+	if st, ok := restoredStats[name]; ok {
+		matchStats[id] = st
+		delete(restoredStats, name)
+	} else {
+		matchStats[id] = &MatchStats{Name: name}
+	}
+	if !matchStarted && orderLen >= 2 {
+		matchStarted = true
+		matchStartTime = time.Now()
+		log.Printf("⚔️ Матч начался (%d игроков)", orderLen)
+	}
+	matchMu.Unlock()
+
+	log.Printf("📥 Игрок подключился: %s (%s) оружие: %s ID: %s на позиции %.0f,%.0f", name, race, weapon, id, x, y)
+
+	servePlayer(c, p, false, chatSince)
+}
+
+// servePlayer отправляет игроку историю чата, init и карту, объявляет о входе и обслуживает
+// его сообщения до отключения. resumed – игрок вернулся на место после потери соединения;
+// chatSince – время последнего сообщения, которое у клиента уже есть (0 – никакого)
+func servePlayer(c *websocket.Conn, p *Player, resumed bool, chatSince int64) {
+	id, name := p.ID, p.Name
+
+	mu.RLock()
+	dead := p.Dead
+	mu.RUnlock()
+
+	// Отправляем историю чата: не больше chatReplay сообщений и только новее chatSince
+	chatMu.RLock()
+	lastMessages := chatHistory
+	if len(lastMessages) > chatReplay {
+		lastMessages = lastMessages[len(lastMessages)-max(chatReplay, 0):]
+	}
+	for len(lastMessages) > 0 && lastMessages[0].Time <= chatSince {
+		lastMessages = lastMessages[1:]
+	}
+	for _, msg := range lastMessages {
+		if canReadChat(dead, msg) {
+			sendToClient(id, chatPayload(msg))
+		}
+	}
+	chatMu.RUnlock()
+
+	// карту берём под mu: перезагрузка (/reload) и новый матч подменяют gameMap под mu.Lock
+	mu.RLock()
+	x, y, col, race, weapon := p.X, p.Y, p.Color, p.Race, p.Weapon
+	mapCopy := gameMap
+	spawnMin, spawnMax := spawnBounds()
+	mu.RUnlock()
+	ws := weaponStats[weapon]
+
+	// Отправляем init
+	sendToClient(id, map[string]any{
+		"type":  "init",
+		"id":    id,
+		"x":     x,
+		"y":     y,
+		"color": col,
+		"race":  race,
+		"rules": map[string]bool{
+			"diagonal_melee": diagonalMelee,
+			"block_corners":  blockCorners,
+		},
+		"weapon": map[string]any{
+			"name":   weapon,
+			"damage": ws.Damage,
+			"range":  ws.Range(),
+		},
+	})
+
+	// Отправляем карту
+	sendToClient(id, map[string]any{
+		"type":  "map",
+		"data":  mapCopy,
+		"spawn": map[string]int{"min": spawnMin, "max": spawnMax},
+	})
+
+	// Объявляем о подключении
+	joinText := fmt.Sprintf("%s присоединился к игре", name)
+	if resumed {
+		joinText = fmt.Sprintf("%s вернулся в игру", name)
+	}
+	chatMsg := ChatMessage{
+		From:  "Система",
+		Text:  joinText,
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	}
+	broadcastChat(chatMsg)
+
+	broadcastToAll()
+
+	// Цикл обработки сообщений от клиента
+	for {
+		var msg map[string]any
+		if err := c.ReadJSON(&msg); err != nil {
+			if errors.Is(err, websocket.ErrReadLimit) {
+				log.Printf("⛔ Игрок %s (ID: %s) прислал сообщение больше %d байт", name, id, maxMessageSize)
+			}
+			log.Printf("📤 Игрок отключился: %s (ID: %s) - %v", name, id, err)
+			break
+		}
+
+		if action, ok := msg["action"].(string); ok {
+			switch action {
+			case "turn_action":
+				handleTurnAction(id, msg)
+			case "chat":
+				handleChat(id, msg)
+			}
+		}
+	}
+
+	// Очистка при отключении: живой игрок в пошаговой игре может ещё вернуться
+	if holdForReconnect(p) {
+		return
+	}
+	removePlayer(p, false)
+}
+
+// holdForReconnect оставляет живого игрока в игре на reconnectGrace после потери соединения:
+// его место в очереди сохраняется, а ходы пропускаются. Возвращает false, если ждать не нужно
+func holdForReconnect(p *Player) bool {
+	if reconnectGrace <= 0 {
+		return false
+	}
+	mu.Lock()
+	if p.Dead || players[p.ID] != p {
+		mu.Unlock()
+		return false
+	}
+	p.Reconnecting = true
+	if conn, ok := conns[p.ID]; ok {
+		conn.mu.Lock()
+		conn.closed = true
+		conn.conn.Close()
+		conn.mu.Unlock()
+		delete(conns, p.ID)
+	}
+	stats.Connections--
+	mu.Unlock()
+
+	log.Printf("⏳ %s потерял соединение, место держится %v", p.Name, reconnectGrace)
+	broadcastChat(ChatMessage{
+		From:  "Система",
+		Text:  fmt.Sprintf("%s потерял соединение и переподключается", p.Name),
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	})
+	broadcastToAll()
+
+	time.AfterFunc(reconnectGrace, func() { removePlayer(p, true) })
+	return true
+}
+
+// takeReconnecting возвращает игроку с именем name его место, если он ждёт переподключения,
+// и привязывает к нему новое соединение
+func takeReconnecting(name string, c *websocket.Conn, ip string) (*Player, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	id, ok := playerNames[name]
+	if !ok {
+		return nil, false
+	}
+	p, ok := players[id]
+	if !ok || !p.Reconnecting {
+		return nil, false
+	}
+	p.Reconnecting = false
+	conns[id] = &Connection{
+		conn:   c,
+		mu:     sync.Mutex{},
+		closed: false,
+		ip:     ip,
+	}
+	stats.Connections++
+	log.Printf("🔌 Игрок %s вернулся на своё место (ID: %s)", name, id)
+	return p, true
+}
+
+// removePlayer окончательно убирает игрока: освобождает имя и цвет, закрывает соединение,
+// удаляет из очереди ходов и объявляет о выходе. expired – срок ожидания переподключения
+// истёк; если игрок к этому времени уже вернулся, ничего не делает
+func removePlayer(p *Player, expired bool) {
+	id, name := p.ID, p.Name
+
+	mu.Lock()
+	if expired && (!p.Reconnecting || players[id] != p) {
+		mu.Unlock()
+		return
+	}
+	delete(players, id)
+	delete(spectators, id)
+	if playerNames[name] == id {
+		delete(playerNames, name)
+	}
+
+	delete(usedColors, colorToKey(p.Color))
+
+	if conn, ok := conns[id]; ok {
+		conn.mu.Lock()
+		conn.closed = true
+		conn.conn.Close()
+		conn.mu.Unlock()
+		delete(conns, id)
+	}
+
+	if !expired {
+		stats.Connections--
+	}
+	mu.Unlock()
+
+	// Удаляем из очереди ходов
+	turnMu.Lock()
+	for i, pid := range playersOrder {
+		if pid == id {
+			playersOrder = append(playersOrder[:i], playersOrder[i+1:]...)
+			if len(playersOrder) == 0 {
+				currentTurn = 0
+			} else {
+				if i < currentTurn {
+					currentTurn--
+				} else if i == currentTurn {
+					if currentTurn >= len(playersOrder) {
+						currentTurn = 0
+					}
+					turnStartTime = time.Now()
+				}
+			}
+			break
+		}
+	}
+	turnMu.Unlock()
+
+	checkMatchEnd()
+
+	chatMsg := ChatMessage{
+		From:  "Система",
+		Text:  fmt.Sprintf("%s покинул игру", name),
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 173, G: 216, B: 230, A: 255},
+	}
+	broadcastChat(chatMsg)
+
+	broadcastToAll()
+
+	log.Printf("❌ Игрок отключился: %s (ID: %s)", name, id)
+}
+
+// reserveUniqueColor – выбирает случайный незанятый цвет и сразу помечает его занятым (вызывать под mu)
+func reserveUniqueColor() Color {
+	for attempt := 0; attempt < 100; attempt++ {
+		c := Color{
+			R: uint8(rand.Intn(200) + 30),
+			G: uint8(rand.Intn(200) + 30),
+			B: uint8(rand.Intn(200) + 30),
+			A: 255,
+		}
+		if key := colorToKey(c); !usedColors[key] {
+			usedColors[key] = true
+			return c
+		}
+	}
+	// Если не удалось найти уникальный, возвращаем случайный
+	c := Color{
+		R: uint8(rand.Intn(200) + 30),
+		G: uint8(rand.Intn(200) + 30),
+		B: uint8(rand.Intn(200) + 30),
+		A: 255,
+	}
+	usedColors[colorToKey(c)] = true
+	return c
+}
+
+// colorToKey – ключ цвета для usedColors
+func colorToKey(c Color) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// отправка сообщения конкретному игроку
+func sendToClient(playerID string, msg map[string]any) {
+	mu.RLock()
+	conn, ok := conns[playerID]
+	mu.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.closed {
+		return
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Ошибка маршалинга для игрока %s: %v", playerID, err)
+		return
+	}
+
+	conn.conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+	if err := conn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		log.Printf("Ошибка отправки игроку %s: %v", playerID, err)
+		conn.closed = true
+		conn.conn.Close()
+	}
+}
+
+// обработка действий
+func handleTurnAction(playerID string, msg map[string]any) {
+	turnMu.RLock()
+	if currentTurnID() != playerID {
+		turnMu.RUnlock()
+		return
+	}
+	turnMu.RUnlock()
+
+	actionType, ok := msg["type"].(string)
+	if !ok {
+		return
+	}
+
+	mu.RLock()
+	p, exists := players[playerID]
+	mu.RUnlock()
+	if !exists || p.Dead {
+		return
+	}
+
+	turnMu.Lock()
+	if !turnPaused && time.Since(turnStartTime) > turnTimeout {
+		nextTurn()
+		turnMu.Unlock()
+		return
+	}
+	turnMu.Unlock()
+
+	switch actionType {
+	case "move":
+		handleTurnMove(p, msg)
+	case "attack":
+		handleTurnAttack(p, msg)
+	case "skip":
+		handleTurnSkip(p)
+	case "heal":
+		handleTurnHeal(p)
+	case "defend":
+		handleTurnDefend(p)
+	case "overwatch":
+		handleTurnOverwatch(p)
+	default:
+		return
+	}
+
+	// погибший в свой ход (выстрел из дозора) уже убран из очереди, и ход перешёл дальше в markDead
+	mu.RLock()
+	died := p.Dead
+	mu.RUnlock()
+	if !died {
+		turnMu.Lock()
+		nextTurn()
+		turnMu.Unlock()
+	}
+
+	broadcastToAll()
+}
+
+// перемещение игрока
+func handleTurnMove(p *Player, msg map[string]any) {
+	targetX, ok1 := msg["targetX"].(float64)
+	targetY, ok2 := msg["targetY"].(float64)
+	if !ok1 || !ok2 {
+		return
+	}
+
+	currentTileX := int(p.X / tileSize)
+	currentTileY := int(p.Y / tileSize)
+	targetTileX := int(targetX / tileSize)
+	targetTileY := int(targetY / tileSize)
+
+	dx := targetTileX - currentTileX
+	dy := targetTileY - currentTileY
+
+	if math.Abs(float64(dx)) > 1 || math.Abs(float64(dy)) > 1 || (dx == 0 && dy == 0) {
+		return
+	}
+
+	if !isPositionValid(targetX, targetY) {
+		return
+	}
+
+	mu.RLock()
+	for _, other := range players {
+		if other.ID != p.ID && !other.Dead {
+			otherTileX := int(other.X / tileSize)
+			otherTileY := int(other.Y / tileSize)
+			if otherTileX == targetTileX && otherTileY == targetTileY {
+				mu.RUnlock()
+				return
+			}
+		}
+	}
+	mu.RUnlock()
+
+	// игрок в дозоре стреляет до того, как шаг завершится; убитый никуда не идёт
+	mu.Lock()
+	shooter := overwatchShooter(p, targetTileX, targetTileY)
+	mu.Unlock()
+	if shooter != nil {
+		log.Printf("👁 %s из дозора бьёт %s", shooter.Name, p.Name)
+		if strike(shooter, p, true) {
+			return
+		}
+	}
+
+	mu.Lock()
+	p.X = targetX
+	p.Y = targetY
+	p.TargetX = targetX
+	p.TargetY = targetY
+	mu.Unlock()
+}
+
+// атака
+func handleTurnAttack(p *Player, msg map[string]any) {
+	targetID, ok := msg["targetID"].(string)
+	if !ok {
+		return
+	}
+
+	mu.RLock()
+	target, exists := players[targetID]
+	mu.RUnlock()
+	if !exists || target.ID == p.ID || target.Dead {
+		return
+	}
+
+	currentTileX := int(p.X / tileSize)
+	currentTileY := int(p.Y / tileSize)
+	targetTileX := int(target.X / tileSize)
+	targetTileY := int(target.Y / tileSize)
+
+	ws, ok := weaponStats[p.Weapon]
+	if !ok || !canWeaponHit(ws, targetTileX-currentTileX, targetTileY-currentTileY) {
+		return
+	}
+	mu.RLock()
+	blocked := cornerBlocked(currentTileX, currentTileY, targetTileX-currentTileX, targetTileY-currentTileY)
+	mu.RUnlock()
+	if blocked {
+		log.Printf("⛔ %s бьёт через угол препятствия – удар отклонён", p.Name)
+		return
+	}
+	strike(p, target, false)
+}
+
+// strike наносит удар p по target: урон оружия (вдвое меньше по защищающемуся), яд,
+// событие "hit" для клиентов, а при смерти цели – сообщение в чат и статистику матча.
+// reaction – удар из дозора в чужой ход. Возвращает true, если цель погибла
+func strike(p, target *Player, reaction bool) bool {
+	mu.RLock()
+	defending := target.Defending
+	mu.RUnlock()
+
+	damage := weaponStats[p.Weapon].Damage
+	if defending {
+		// защита делит урон пополам, но совсем не отменяет удар
+		damage = max(damage/2, 1)
+	}
+
+	// событие попадания: клиент цели по координатам атакующего рисует индикатор направления
+	hitMsg := map[string]any{
+		"type":     "hit",
+		"attacker": p.ID,
+		"target":   target.ID,
+		"damage":   damage,
+		"defended": defending,
+		"reaction": reaction,
+		"ax":       p.X,
+		"ay":       p.Y,
+	}
+
+	mu.Lock()
+	target.HP -= damage
+	if poisonWeapon != "" && p.Weapon == poisonWeapon && target.HP > 0 {
+		target.Poison = Poison{
+			Stacks: min(target.Poison.Stacks+1, maxPoison),
+			Turns:  poisonTurns,
+			From:   p.ID,
+		}
+	}
+	if target.HP <= 0 && !target.Dead {
+		markDead(target)
+
+		chatMsg := ChatMessage{
+			From:  "Система",
+			Text:  fmt.Sprintf("%s был убит", target.Name),
+			Time:  time.Now().UnixMilli(),
+			Color: Color{R: 255, G: 100, B: 100, A: 255},
+		}
+		mu.Unlock()
+
+		announcements := recordKill(p.ID, target.ID)
+
+		broadcastMessage(hitMsg)
+		broadcastChat(chatMsg)
+		announce(announcements)
+		checkMatchEnd()
+		return true
+	}
+	mu.Unlock()
+
+	broadcastMessage(hitMsg)
+	return false
+}
+
+// markDead помечает игрока погибшим, убирает его из очереди ходов и освобождает имя. Вызывать под mu
+func markDead(target *Player) {
+	target.Dead = true
+	target.DeathTime = time.Now()
+	target.Poison = Poison{}
+	target.Defending = false
+	target.Overwatch = false
+	// зрителем он остаётся, пока подключён, – даже после того, как cleanupLoop уберёт его из players
+	spectators[target.ID] = target
+
+	turnMu.Lock()
+	for i, pid := range playersOrder {
+		if pid == target.ID {
+			playersOrder = append(playersOrder[:i], playersOrder[i+1:]...)
+			if i < currentTurn {
+				currentTurn--
+			} else if i == currentTurn {
+				if currentTurn >= len(playersOrder) {
+					currentTurn = 0
+				}
+				turnStartTime = time.Now()
+			}
+			break
+		}
+	}
+	turnMu.Unlock()
+
+	delete(playerNames, target.Name)
+}
+
+// tickPoison в начале хода отравленного игрока снимает с него урон от яда.
+// Яд не добивает игрока, стоящего в безопасной зоне появления
+func tickPoison() {
+	turnMu.RLock()
+	id := currentTurnID()
+	if id == "" || turnPaused {
+		turnMu.RUnlock()
+		return
+	}
+	started := turnStartTime
+	turnMu.RUnlock()
+
+	mu.Lock()
+	if started.Equal(poisonTickedAt) {
+		mu.Unlock()
+		return
+	}
+	poisonTickedAt = started
+	p := players[id]
+	if p == nil || p.Dead || p.Poison.Turns <= 0 {
+		mu.Unlock()
+		return
+	}
+	damage := p.Poison.Stacks
+	if inSpawnZone(p) {
+		damage = min(damage, p.HP-1)
+	}
+	p.HP -= damage
+	poisoner := p.Poison.From
+	p.Poison.Turns--
+	if p.Poison.Turns <= 0 {
+		p.Poison = Poison{}
+	}
+	killed := p.HP <= 0
+	if killed {
+		markDead(p)
+	}
+	name := p.Name
+	mu.Unlock()
+
+	log.Printf("☠️ Яд: %s теряет %d HP", name, damage)
+	if !killed {
+		broadcastToAll()
+		return
+	}
+
+	announcements := recordKill(poisoner, id)
+
+	broadcastChat(ChatMessage{
+		From:  "Система",
+		Text:  fmt.Sprintf("%s погиб от яда", name),
+		Time:  time.Now().UnixMilli(),
+		Color: Color{R: 255, G: 100, B: 100, A: 255},
+	})
+	announce(announcements)
+	broadcastToAll()
+	checkMatchEnd()
+}
+
+// streakThresholds – серии убийств, о которых объявляется при -kill-announce
+var streakThresholds = []int{3, 5, 7, 10}
+
+// recordKill засчитывает убийство в статистику матча (самоубийство ядом – только смерть)
+// и возвращает объявления: первая кровь матча и достигнутая серия убийцы
+func recordKill(killerID, victimID string) []string {
+	matchMu.Lock()
+	defer matchMu.Unlock()
+
+	var announcements []string
+	if st, ok := matchStats[victimID]; ok {
+		st.Deaths++
+		st.Streak = 0
+	}
+	st, ok := matchStats[killerID]
+	if !ok || killerID == victimID {
+		return nil
+	}
+	firstBlood := true
+	for _, other := range matchStats {
+		if other.Kills > 0 {
+			firstBlood = false
+			break
+		}
+	}
+	st.Kills++
+	st.Streak++
+	if !killAnnounce {
+		return nil
+	}
+	if firstBlood {
+		announcements = append(announcements, fmt.Sprintf("Первая кровь! %s открывает счёт", st.Name))
+	}
+	for _, t := range streakThresholds {
+		if st.Streak == t {
+			announcements = append(announcements, fmt.Sprintf("%s на серии из %d!", st.Name, t))
+		}
+	}
+	return announcements
+}
+
+// announce рассылает объявления: в чат от системы и отдельным сообщением "announce",
+// которое клиент показывает крупно посреди экрана
+func announce(texts []string) {
+	for _, text := range texts {
+		log.Printf("📣 %s", text)
+		broadcastChat(ChatMessage{
+			From:  "Система",
+			Text:  text,
+			Time:  time.Now().UnixMilli(),
+			Color: Color{R: 255, G: 200, B: 60, A: 255},
+		})
+		broadcastMessage(map[string]any{
+			"type": "announce",
+			"text": text,
+		})
+	}
+}
+
+// unstickCurrent один раз за ход проверяет, не застрял ли игрок, чей сейчас ход:
+// из-за округления он может оказаться там, где его позиция недопустима или
+// ни один соседний ход не проходит. Такого игрока переносим в ближайшую свободную клетку
+func unstickCurrent() {
+	turnMu.RLock()
+	id := currentTurnID()
+	if id == "" {
+		turnMu.RUnlock()
+		return
+	}
+	started := turnStartTime
+	turnMu.RUnlock()
+
+	mu.Lock()
+	if started.Equal(unstickCheckedAt) {
+		mu.Unlock()
+		return
+	}
+	unstickCheckedAt = started
+	p := players[id]
+	if p == nil || p.Dead || !isStuck(p) {
+		mu.Unlock()
+		return
+	}
+	x, y, ok := findNearestValidTile(p.X, p.Y, func(tx, ty int) bool { return !tileTaken(tx, ty, p) })
+	if ok {
+		fromX, fromY := p.X, p.Y
+		p.X, p.Y = x, y
+		p.TargetX, p.TargetY = x, y
+		log.Printf("🧲 Игрок %s застрял в %.0f,%.0f и перенесён в %.0f,%.0f", p.Name, fromX, fromY, x, y)
+	} else {
+		log.Printf("🧲 Игрок %s застрял, но свободной клетки рядом нет", p.Name)
+	}
+	mu.Unlock()
+
+	if ok {
+		broadcastToAll()
+	}
+}
+
+// isStuck – позиция игрока недопустима или ни в одну соседнюю клетку не сходить из-за
+// препятствий (другие игроки не в счёт – они уйдут). Вызывать под mu
+func isStuck(p *Player) bool {
+	return !isPositionValid(p.X, p.Y) || !hasExit(int(p.X/tileSize), int(p.Y/tileSize))
+}
+
+// hasExit – из клетки можно сходить хотя бы в одну соседнюю
+func hasExit(tx, ty int) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && isPositionValid(tileCenter(tx+dx), tileCenter(ty+dy)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findNearestValidTile ищет кольцами вокруг точки (px, py) ближайшую клетку, в центре которой
+// можно стоять, из которой есть ход и которую пропускает free. Вызывать под mu
+func findNearestValidTile(px, py float64, free func(tx, ty int) bool) (float64, float64, bool) {
+	const maxRadius = 10
+	tx, ty := int(px/tileSize), int(py/tileSize)
+	for r := 0; r <= maxRadius; r++ {
+		bestX, bestY, bestDist, found := 0.0, 0.0, math.MaxFloat64, false
+		for y := ty - r; y <= ty+r; y++ {
+			for x := tx - r; x <= tx+r; x++ {
+				if abs(x-tx) != r && abs(y-ty) != r {
+					continue // внутренние клетки уже проверены на меньших кольцах
+				}
+				cx, cy := tileCenter(x), tileCenter(y)
+				if !isPositionValid(cx, cy) || !hasExit(x, y) || !free(x, y) {
+					continue
+				}
+				if d := math.Hypot(cx-px, cy-py); d < bestDist {
+					bestX, bestY, bestDist, found = cx, cy, d, true
+				}
+			}
+		}
+		if found {
+			return bestX, bestY, true
+		}
+	}
+	return 0, 0, false
+}
+
+// tileCenter – координата центра клетки с индексом t
+func tileCenter(t int) float64 {
+	return float64(t*tileSize + tileSize/2)
+}
+
+// tileTaken – стоит ли в клетке живой игрок, кроме self. Вызывать под mu
+func tileTaken(x, y int, self *Player) bool {
+	for _, other := range players {
+		if other != self && !other.Dead && int(other.X/tileSize) == x && int(other.Y/tileSize) == y {
+			return true
+		}
+	}
+	return false
+}
+
+// inSpawnZone – стоит ли игрок в безопасной зоне появления. Вызывать под mu
+func inSpawnZone(p *Player) bool {
+	lo, hi := spawnBounds()
+	tx, ty := int(p.X/tileSize), int(p.Y/tileSize)
+	return tx >= lo && tx <= hi && ty >= lo && ty <= hi
+}
+
+// checkMatchEnd – если матч идёт и в живых остался один игрок (или никого),
+// объявляет итоги матча всем подключённым
+func checkMatchEnd() {
+	mu.RLock()
+	var alive []*Player
+	for _, p := range players {
+		if !p.Dead {
+			alive = append(alive, p)
+		}
+	}
+	mu.RUnlock()
+
+	matchMu.Lock()
+	if !matchStarted || len(alive) > 1 {
+		matchMu.Unlock()
+		return
+	}
+	matchStarted = false
+	duration := time.Since(matchStartTime)
+
+	winner, winnerID := "", ""
+	if len(alive) == 1 {
+		winner = alive[0].Name
+		winnerID = alive[0].ID
+	}
+
+	scoreboard := make([]MatchStats, 0, len(matchStats))
+	for _, st := range matchStats {
+		scoreboard = append(scoreboard, *st)
+	}
+	sort.Slice(scoreboard, func(i, j int) bool {
+		if scoreboard[i].Kills != scoreboard[j].Kills {
+			return scoreboard[i].Kills > scoreboard[j].Kills
+		}
+		return scoreboard[i].Deaths < scoreboard[j].Deaths
+	})
+
+	// статистика следующего матча начинается с оставшихся игроков
+	matchStats = make(map[string]*MatchStats)
+	restoredStats = nil
+	for _, p := range alive {
+		matchStats[p.ID] = &MatchStats{Name: p.Name}
+	}
+	matchMu.Unlock()
+
+	log.Printf("🏆 Матч окончен. Победитель: %q, длительность: %v", winner, duration.Round(time.Second))
+
+	broadcastMessage(map[string]any{
+		"type":       "game_over",
+		"winner":     winner,
+		"winner_id":  winnerID,
+		"duration":   duration.Seconds(),
+		"scoreboard": scoreboard,
+	})
+
+	if autoRestart {
+		go scheduleRestart()
+	}
+}
+
+// broadcastMessage – отправка сообщения всем подключённым
+func broadcastMessage(msg map[string]any) {
+	mu.RLock()
+	ids := make([]string, 0, len(conns))
+	for id := range conns {
+		ids = append(ids, id)
+	}
+	mu.RUnlock()
+
+	for _, id := range ids {
+		sendToClient(id, msg)
+	}
+}
+
+// canWeaponHit – попадает ли смещение (dx, dy) в форму атаки оружия
+func canWeaponHit(ws WeaponStats, dx, dy int) bool {
+	for _, off := range ws.Shape {
+		if off[0] == dx && off[1] == dy {
+			return true
+		}
+	}
+	return false
+}
+
+// withDiagonals возвращает копию оружия, которое дополнительно достаёт до диагональных соседей
+func withDiagonals(ws WeaponStats) WeaponStats {
+	shape := make([][2]int, 0, len(ws.Shape)+4)
+	shape = append(shape, ws.Shape...)
+	ws.Shape = append(shape, [2]int{1, 1}, [2]int{1, -1}, [2]int{-1, 1}, [2]int{-1, -1})
+	return ws
+}
+
+// cornerBlocked – диагональный удар из клетки (x, y) со смещением (dx, dy) упирается в угол:
+// обе соседние по стороне клетки между атакующим и целью непроходимы. Вызывать под mu
+func cornerBlocked(x, y, dx, dy int) bool {
+	if !blockCorners || dx == 0 || dy == 0 {
+		return false
+	}
+	return !freeTile(x+dx, y) && !freeTile(x, y+dy)
+}
+
+// freeTile – клетка внутри карты и проходима
+func freeTile(x, y int) bool {
+	return x >= 0 && y >= 0 && x < mapW && y < mapH && gameMap[y][x] == 0
+}
+
+// пропуск хода
+func handleTurnSkip(p *Player) {
+}
+
+// защита: игрок пропускает ход, но до начала своего следующего хода получает вдвое меньше урона
+func handleTurnDefend(p *Player) {
+	turnMu.RLock()
+	started := turnStartTime
+	turnMu.RUnlock()
+
+	mu.Lock()
+	p.Defending = true
+	p.StanceOn = started
+	mu.Unlock()
+	log.Printf("🛡 %s защищается", p.Name)
+}
+
+// дозор: игрок с оружием дальше соседней клетки пропускает ход и до начала своего
+// следующего хода бьёт первого врага, который шагнёт в зону его удара (см. overwatchShooter).
+// Для оружия ближнего боя это обычный пропуск хода
+func handleTurnOverwatch(p *Player) {
+	if weaponStats[p.Weapon].Range() < 2 {
+		return
+	}
+	turnMu.RLock()
+	started := turnStartTime
+	turnMu.RUnlock()
+
+	mu.Lock()
+	p.Overwatch = true
+	p.StanceOn = started
+	mu.Unlock()
+	log.Printf("👁 %s в дозоре", p.Name)
+}
+
+// overwatchShooter – игрок в дозоре, в чью досягаемость mover входит шагом на клетку (tx, ty):
+// с исходной клетки удар не доставал, а до (tx, ty) достаёт – по форме удара, без угла
+// препятствия и камней на линии. Кто уже был в досягаемости, выстрела не вызывает.
+// Дозор при этом расходуется. nil – никто не стреляет. Вызывать под mu.Lock
+func overwatchShooter(mover *Player, tx, ty int) *Player {
+	for _, o := range players {
+		if !o.Overwatch || o.Dead || o.ID == mover.ID {
+			continue
+		}
+		ox, oy := int(o.X/tileSize), int(o.Y/tileSize)
+		ws := weaponStats[o.Weapon]
+		if canWeaponHit(ws, int(mover.X/tileSize)-ox, int(mover.Y/tileSize)-oy) {
+			continue
+		}
+		dx, dy := tx-ox, ty-oy
+		if !canWeaponHit(ws, dx, dy) || cornerBlocked(ox, oy, dx, dy) || !lineOfSight(ox, oy, tx, ty) {
+			continue
+		}
+		o.Overwatch = false
+		return o
+	}
+	return nil
+}
+
+// endStance снимает защиту и дозор с игрока, к которому перешёл ход
+func endStance() {
+	turnMu.RLock()
+	id := currentTurnID()
+	if id == "" {
+		turnMu.RUnlock()
+		return
+	}
+	started := turnStartTime
+	turnMu.RUnlock()
+
+	mu.Lock()
+	if started.Equal(stanceCheckedAt) {
+		mu.Unlock()
+		return
+	}
+	stanceCheckedAt = started
+	p := players[id]
+	// ход, в котором игрок только что встал в стойку, ещё не закончился
+	ended := p != nil && (p.Defending || p.Overwatch) && !p.StanceOn.Equal(started)
+	if ended {
+		p.Defending = false
+		p.Overwatch = false
+	}
+	mu.Unlock()
+
+	if ended {
+		broadcastToAll()
+	}
+}
+
+// лечение: игрок тратит ход, чтобы восстановить немного здоровья
+func handleTurnHeal(p *Player) {
+	mu.Lock()
+	p.HP = min(p.HP+healAmount, maxHP)
+	mu.Unlock()
+}
+
+// обработка сообщения чата
+func handleChat(id string, msg map[string]any) {
+	// погибший игрок наблюдает за матчем и пишет в канал зрителей
+	mu.RLock()
+	p, spectator := spectators[id]
+	exists := spectator
+	if !spectator {
+		p, exists = players[id]
+	}
+	mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	text, ok := msg["text"].(string)
+	if !ok || text == "" {
+		return
+	}
+
+	if len(text) > 200 {
+		text = text[:200]
+	}
+
+	chatMsg := ChatMessage{
+		From:      p.Name,
+		Text:      text,
+		Time:      time.Now().UnixMilli(),
+		Color:     p.Color,
+		Spectator: spectator,
+	}
+
+	broadcastChat(chatMsg)
+	statsMu.Lock()
+	stats.ChatMessages++
+	statsMu.Unlock()
+}
+
+// рассылка сообщения чата всем
+func broadcastChat(msg ChatMessage) {
+	chatMu.Lock()
+	chatHistory = append(chatHistory, msg)
+	if len(chatHistory) > 1000 {
+		chatHistory = chatHistory[len(chatHistory)-1000:]
+	}
+	chatMu.Unlock()
+
+	if chatLogFile != "" {
+		chatLogMu.Lock()
+		chatLogPending = append(chatLogPending, msg)
+		chatLogMu.Unlock()
+	}
+
+	// рассылка идёт вне mu: список соединений копируется,
+	// поэтому медленный клиент не блокирует broadcastToAll и подключения
+	if !msg.Spectator {
+		broadcastMessage(chatPayload(msg))
+		return
+	}
+
+	// сообщение зрителя получают только зрители (и игроки, если это разрешено)
+	mu.RLock()
+	ids := make([]string, 0, len(conns))
+	for id := range conns {
+		_, watching := spectators[id]
+		if _, ok := players[id]; (ok || watching) && canReadChat(watching, msg) {
+			ids = append(ids, id)
+		}
+	}
+	mu.RUnlock()
+
+	payload := chatPayload(msg)
+	for _, id := range ids {
+		sendToClient(id, payload)
+	}
+}
+
+// chatPayload собирает сетевое сообщение чата
+func chatPayload(msg ChatMessage) map[string]any {
+	return map[string]any{
+		"type":      "chat",
+		"from":      msg.From,
+		"text":      msg.Text,
+		"time":      msg.Time,
+		"color":     msg.Color,
+		"spectator": msg.Spectator,
+	}
+}
+
+// canReadChat – видит ли сообщение игрок (dead – он сейчас зритель)
+func canReadChat(dead bool, msg ChatMessage) bool {
+	return !msg.Spectator || dead || spectatorChatToPlayers
+}
+
+// проверка, можно ли находиться в точке
+func isPositionValid(x, y float64) bool {
+	points := []struct{ dx, dy float64 }{
+		{0, 0},
+		{-tileSize / 3, -tileSize / 3},
+		{tileSize / 3, -tileSize / 3},
+		{-tileSize / 3, tileSize / 3},
+		{tileSize / 3, tileSize / 3},
+		{-tileSize / 3, 0},
+		{tileSize / 3, 0},
+		{0, -tileSize / 3},
+		{0, tileSize / 3},
+	}
+
+	for _, point := range points {
+		tx := int((x + point.dx) / float64(tileSize))
+		ty := int((y + point.dy) / float64(tileSize))
+
+		if tx < 0 || ty < 0 || tx >= mapW || ty >= mapH {
+			return false
+		}
+
+		if gameMap[ty][tx] != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// периодическая рассылка состояния
+func broadcastLoop() {
+	ticker := time.NewTicker(33 * time.Millisecond)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		broadcastToAll()
+	}
+}
+
+// формирует и рассылает состояние всем игрокам
+func broadcastToAll() {
+	mu.RLock()
+
+	if len(players) == 0 {
+		mu.RUnlock()
+		return
+	}
+
+	buildStart := time.Now()
+
+	alive := make([]*Player, 0, len(players))
+	entries := make([]map[string]any, 0, len(players))
+	for _, p := range players {
+		if p.Dead {
+			continue
+		}
+		alive = append(alive, p)
+		entries = append(entries, map[string]any{
+			"id":           p.ID,
+			"name":         p.Name,
+			"race":         p.Race,
+			"weapon":       p.Weapon,
+			"x":            p.X,
+			"y":            p.Y,
+			"tx":           p.TargetX,
+			"ty":           p.TargetY,
+			"hp":           p.HP,
+			"color":        p.Color,
+			"poison":       p.Poison.Stacks,
+			"defending":    p.Defending,
+			"overwatch":    p.Overwatch,
+			"reconnecting": p.Reconnecting,
+		})
+	}
+
+	msg := map[string]any{
+		"type": "state",
+		"ts":   time.Now().UnixMilli(),
+		"data": entries,
+	}
+
+	turnMu.RLock()
+	msg["turn_order"] = append([]string(nil), playersOrder...)
+	if len(playersOrder) > 0 {
+		msg["current_turn"] = currentTurnID()
+		msg["turn_started_at"] = turnStartTime.UnixMilli()
+		timeLeft := (turnTimeout - time.Since(turnStartTime)).Seconds()
+		if turnPaused {
+			timeLeft = turnTimeout.Seconds()
+		}
+		if timeLeft < 0 {
+			timeLeft = 0
+		}
+		msg["turn_time_left"] = timeLeft
+	}
+	msg["waiting_for_players"] = turnPaused
+	turnMu.RUnlock()
+
+	// Без области видимости всем уходит одно и то же сообщение
+	var shared []byte
+	if viewRadius <= 0 {
+		var err error
+		if shared, err = json.Marshal(msg); err != nil {
+			mu.RUnlock()
+			log.Println("Ошибка маршалинга:", err)
+			return
+		}
+	}
+
+	for id, conn := range conns {
+		data := shared
+		if data == nil {
+			// Погибший игрок смотрит матч зрителем и видит всех.
+			// Живые игроки вне обзора перечислены в "hidden", чтобы клиент
+			// не принял их исчезновение из "data" за гибель
+			viewer := players[id]
+			visible := entries
+			var hidden []string
+			if viewer != nil && !viewer.Dead {
+				visible = make([]map[string]any, 0, len(entries))
+				for i, p := range alive {
+					if p == viewer || canSee(viewer, p) {
+						visible = append(visible, entries[i])
+					} else {
+						hidden = append(hidden, p.ID)
+					}
+				}
+			}
+			msg["data"] = visible
+			msg["hidden"] = hidden
+			var err error
+			if data, err = json.Marshal(msg); err != nil {
+				log.Println("Ошибка маршалинга:", err)
+				continue
+			}
+		}
+
+		go func(playerID string, conn *Connection, data []byte) {
+			conn.mu.Lock()
+			defer conn.mu.Unlock()
+
+			if conn.closed {
+				return
+			}
+
+			conn.conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+			if err := conn.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("Ошибка отправки игроку %s: %v", playerID, err)
+				conn.closed = true
+				conn.conn.Close()
+			}
+		}(id, conn, data)
+	}
+
+	buildTime := time.Since(buildStart)
+	mu.RUnlock()
+
+	// рассылку вызывают одновременно цикл ходов, очистка и обработчики соединений,
+	// поэтому замеры пишутся под своим мьютексом, а не под RLock
+	statsMu.Lock()
+	stats.MessagesSent++
+	stats.LastUpdate = time.Now()
+	stats.BroadcastTime = buildTime
+	stats.BroadcastMax = max(stats.BroadcastMax, buildTime)
+	statsMu.Unlock()
+}
+
+// canSee – попадает ли игрок p в область видимости viewer: не дальше viewRadius клеток
+// по каждой оси и, если включено -view-los, не закрыт камнями (вызывать под mu)
+func canSee(viewer, p *Player) bool {
+	x0, y0 := int(viewer.X/tileSize), int(viewer.Y/tileSize)
+	x1, y1 := int(p.X/tileSize), int(p.Y/tileSize)
+	if abs(x1-x0) > viewRadius || abs(y1-y0) > viewRadius {
+		return false
+	}
+	return !viewLOS || lineOfSight(x0, y0, x1, y1)
+}
+
+// abs – модуль целого числа
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// lineOfSight проходит по клеткам отрезка (алгоритм Брезенхэма) и проверяет,
+// что между концами нет камней. Сами концы не проверяются
+func lineOfSight(x0, y0, x1, y1 int) bool {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	x, y := x0, y0
+	for {
+		if x == x1 && y == y1 {
+			return true
+		}
+		if (x != x0 || y != y0) && y >= 0 && y < mapH && x >= 0 && x < mapW && gameMap[y][x] == 2 {
+			return false
+		}
+		if 2*e >= dy {
+			e += dy
+			x += sx
+		}
+		if 2*e <= dx {
+			e += dx
+			y += sy
+		}
+	}
+}
+
+// генерация карты
+func genMap() {
+	gameMap = make([][]int, mapH)
+	for y := 0; y < mapH; y++ {
+		gameMap[y] = make([]int, mapW)
+		for x := 0; x < mapW; x++ {
+			gameMap[y][x] = 0
+		}
+	}
+
+	// Безопасная зона в центре (5x5)
+	centerMin, centerMax := spawnBounds()
+	for y := centerMin; y <= centerMax; y++ {
+		for x := centerMin; x <= centerMax; x++ {
+			gameMap[y][x] = 0
+		}
+	}
+
+	isInCenter := func(x, y int) bool {
+		return x >= centerMin && x <= centerMax && y >= centerMin && y <= centerMax
+	}
+
+	// Озёра
+	numLakes := rand.Intn(5) + 5
+	for i := 0; i < numLakes; i++ {
+		attempts := 0
+		for {
+			attempts++
+			if attempts > 100 {
+				break
+			}
+			cx := rand.Intn(mapW-20) + 10
+			cy := rand.Intn(mapH-20) + 10
+			if isInCenter(cx, cy) {
+				continue
+			}
+			rx := rand.Intn(6) + 4
+			ry := rand.Intn(6) + 4
+
+			for dy := -ry; dy <= ry; dy++ {
+				for dx := -rx; dx <= rx; dx++ {
+					if dx*dx*ry*ry+dy*dy*rx*rx <= rx*rx*ry*ry {
+						x := cx + dx
+						y := cy + dy
+						if x >= 0 && x < mapW && y >= 0 && y < mapH && !isInCenter(x, y) {
+							gameMap[y][x] = 1
+						}
+					}
+				}
+			}
+			break
+		}
+	}
+
+	// Камни
+	numRocks := rand.Intn(10) + 10
+	for i := 0; i < numRocks; i++ {
+		attempts := 0
+		for {
+			attempts++
+			if attempts > 100 {
+				break
+			}
+			cx := rand.Intn(mapW-12) + 6
+			cy := rand.Intn(mapH-12) + 6
+			if isInCenter(cx, cy) {
+				continue
+			}
+			size := rand.Intn(8) + 5
+			generateRock(gameMap, cx, cy, size)
+			break
+		}
+	}
+
+	log.Printf("Карта сгенерирована: %dx%d тайлов", mapW, mapH)
+	log.Printf("Безопасная зона в центре: %dx%d клеток", 2*spawnRadius+1, 2*spawnRadius+1)
+}
+
+// spawnBounds – границы безопасной зоны в клетках (включительно, одинаковые по X и Y)
+func spawnBounds() (int, int) {
+	return mapW/2 - spawnRadius, mapW/2 + spawnRadius
+}
+
+// поиск свободной клетки в безопасной зоне
+func findSafeSpawn() (float64, float64) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return pickSpawn(livePositions())
+}
+
+// findRespawn – место возвращения в игру погибшего в (deathX, deathY) по правилу -respawn-at
+func findRespawn(deathX, deathY float64) (float64, float64) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return respawnPoint(deathX, deathY, livePositions())
+}
+
+// livePositions – позиции живых игроков (вызывать под mu)
+func livePositions() [][2]float64 {
+	var occupied [][2]float64
+	for _, p := range players {
+		if !p.Dead {
+			occupied = append(occupied, [2]float64{p.X, p.Y})
+		}
+	}
+	return occupied
+}
+
+// respawnPoint выбирает, где вернуть в игру погибшего в (deathX, deathY): при -respawn-at=death –
+// ближайшая к месту гибели допустимая клетка не ближе полутора тайлов к занятым точкам,
+// иначе (или если такой рядом нет) – безопасная зона, как при входе. Вызывать под mu
+func respawnPoint(deathX, deathY float64, occupied [][2]float64) (float64, float64) {
+	if respawnAt == "death" {
+		x, y, ok := findNearestValidTile(deathX, deathY, func(tx, ty int) bool {
+			return farFromAll(tileCenter(tx), tileCenter(ty), occupied)
+		})
+		if ok {
+			return x, y
+		}
+	}
+	return pickSpawn(occupied)
+}
+
+// farFromAll – точка не ближе полутора тайлов ко всем занятым точкам
+func farFromAll(px, py float64, occupied [][2]float64) bool {
+	for _, o := range occupied {
+		if math.Hypot(px-o[0], py-o[1]) < float64(tileSize)*1.5 {
+			return false
+		}
+	}
+	return true
+}
+
+// pickSpawn – случайная проходимая клетка в безопасной зоне, не ближе полутора тайлов
+// к занятым точкам. Если зона заполнена, поиск расширяется кольцами вокруг неё.
+// Читает gameMap – вызывать под mu.
+func pickSpawn(occupied [][2]float64) (float64, float64) {
+	centerMin, centerMax := spawnBounds()
+
+	for grow := 0; grow <= 5; grow++ {
+		lo, hi := max(centerMin-grow, 0), min(centerMax+grow, mapW-1)
+		var candidates []struct{ x, y int }
+		for y := lo; y <= hi; y++ {
+			for x := lo; x <= hi; x++ {
+				onRing := x == lo || x == hi || y == lo || y == hi
+				if (grow == 0 || onRing) && y < len(gameMap) && gameMap[y][x] == 0 {
+					candidates = append(candidates, struct{ x, y int }{x, y})
+				}
+			}
+		}
+
+		rand.Shuffle(len(candidates), func(i, j int) {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		})
+
+		for _, c := range candidates {
+			px, py := tileCenter(c.x), tileCenter(c.y)
+			if farFromAll(px, py, occupied) {
+				return px, py
+			}
+		}
+	}
+
+	return float64(centerMin*tileSize + tileSize/2), float64(centerMin*tileSize + tileSize/2)
+}
+
+// генерация случайного 8-символьного ID
+func randID() string {
+	const charset = "abcdef0123456789"
+	b := make([]byte, 8)
+	for i := range b {
+		b[i] = charset[rand.Intn(len(charset))]
+	}
+	return string(b)
+}
+
+// периодический вывод статистики в лог
+func statsLoop() {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		mu.RLock()
+		playerCount, connections := len(players), stats.Connections
+		mu.RUnlock()
+
+		statsMu.Lock()
+		stats.Players = playerCount
+		uptime := time.Since(stats.StartTime).Round(time.Second)
+		rec := StatsRecord{
+			Time:         time.Now().Format(time.RFC3339),
+			Players:      stats.Players,
+			Connections:  connections,
+			MessagesSent: stats.MessagesSent,
+			ChatMessages: stats.ChatMessages,
+			UptimeSec:    int64(uptime.Seconds()),
+		}
+		lastBroadcast, maxBroadcast := stats.BroadcastTime, stats.BroadcastMax
+		stats.BroadcastMax = 0
+		statsMu.Unlock()
+
+		log.Printf("📊 Статистика: Игроки: %d, Сообщений: %d, Чат: %d, Рассылка: %v (макс. %v), Аптайм: %v",
+			rec.Players, rec.MessagesSent, rec.ChatMessages, lastBroadcast, maxBroadcast, uptime)
+		if statsFile != "" {
+			appendStats(rec)
+		}
+	}
+}
+
+// appendStats дописывает замер в statsFile: в .csv – строкой CSV (заголовок в новом файле),
+// в остальные – объектом JSON на строку. Ошибка записи только логируется
+func appendStats(rec StatsRecord) {
+	csvFormat := strings.EqualFold(filepath.Ext(statsFile), ".csv")
+	info, err := os.Stat(statsFile)
+	empty := err != nil || info.Size() == 0
+
+	f, err := os.OpenFile(statsFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Println("Ошибка открытия файла статистики:", err)
+		return
+	}
+	w := bufio.NewWriter(f)
+	if csvFormat {
+		if empty {
+			fmt.Fprintln(w, "time,players,connections,messages_sent,chat_messages,uptime_sec")
+		}
+		fmt.Fprintf(w, "%s,%d,%d,%d,%d,%d\n", rec.Time, rec.Players, rec.Connections,
+			rec.MessagesSent, rec.ChatMessages, rec.UptimeSec)
+	} else if err := json.NewEncoder(w).Encode(rec); err != nil {
+		log.Println("Ошибка записи статистики:", err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Println("Ошибка записи статистики:", err)
+	}
+	if err := f.Close(); err != nil {
+		log.Println("Ошибка записи статистики:", err)
+	}
+}
+
+// периодическая очистка мёртвых игроков и закрытых соединений
+func cleanupLoop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		cleanup(now)
+	}
+}
+
+// cleanup закрывает оборванные соединения и убирает из списка давно погибших игроков
+func cleanup(now time.Time) {
+	mu.Lock()
+	toRemove := []string{}
+
+	for id, conn := range conns {
+		conn.mu.Lock()
+		if conn.closed {
+			toRemove = append(toRemove, id)
+		}
+		conn.mu.Unlock()
+	}
+
+	for _, id := range toRemove {
+		if conn, ok := conns[id]; ok {
+			conn.conn.Close()
+			delete(conns, id)
+		}
+		delete(spectators, id)
+		if p, ok := players[id]; ok {
+			delete(playerNames, p.Name)
+			delete(players, id)
+		}
+	}
+
+	// погибшие игроки убираются из списка, но соединение остаётся открытым:
+	// клиент может продолжать наблюдать за матчем
+	for id, p := range players {
+		// при автоперезапуске погибшие ждут следующего матча, пока подключены
+		if p.Dead && !autoRestart && now.Sub(p.DeathTime) > 30*time.Second {
+			delete(players, id)
+			if playerNames[p.Name] == id {
+				delete(playerNames, p.Name)
+			}
+		}
+	}
+	mu.Unlock()
+}
+
+// HTTP-обработчик для статистики
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+
+	mu.RLock()
+	connections := stats.Connections
+	mu.RUnlock()
+
+	statsMu.Lock()
+	uptime := time.Since(stats.StartTime).Round(time.Second)
+
+	statsData := map[string]any{
+		"players":       stats.Players,
+		"max_players":   maxPlayers,
+		"connections":   connections,
+		"messages_sent": stats.MessagesSent,
+		"chat_messages": stats.ChatMessages,
+		"uptime":        uptime.String(),
+		"last_update":   stats.LastUpdate.Format("15:04:05"),
+		"map_size":      fmt.Sprintf("%dx%d", mapW, mapH),
+		"broadcast_us":  stats.BroadcastTime.Microseconds(),
+	}
+	statsMu.Unlock()
+
+	writeJSON(w, statsData)
+}
@@ -0,0 +1,431 @@
+package gameserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// resetState очищает глобальное состояние сервера и генерирует новую карту
+func resetState(t *testing.T) {
+	t.Helper()
+	mu.Lock()
+	players = make(map[string]*Player)
+	playerNames = make(map[string]string)
+	conns = make(map[string]*Connection)
+	spectators = make(map[string]*Player)
+	usedColors = make(map[uint32]bool)
+	genMap()
+	mu.Unlock()
+
+	turnMu.Lock()
+	playersOrder = nil
+	currentTurn = 0
+	turnStartTime = time.Now()
+	turnMu.Unlock()
+
+	matchMu.Lock()
+	matchStats = make(map[string]*MatchStats)
+	matchStarted = false
+	matchMu.Unlock()
+
+	chatMu.Lock()
+	chatHistory = nil
+	chatMu.Unlock()
+}
+
+func TestCanWeaponHit(t *testing.T) {
+	cases := []struct {
+		name     string
+		weapon   string
+		diagonal bool // как с -diagonal-melee
+		dx, dy   int
+		want     bool
+	}{
+		{"меч рядом", "sword", false, 1, 0, true},
+		{"меч рядом сверху", "sword", false, 0, -1, true},
+		{"меч на 2 клетки", "sword", false, 2, 0, false},
+		{"меч на 2 клетки вниз", "sword", false, 0, 2, false},
+		{"меч по диагонали без флага", "sword", false, 1, 1, false},
+		{"меч по диагонали с флагом", "sword", true, 1, 1, true},
+		{"меч по другой диагонали с флагом", "sword", true, -1, 1, true},
+		{"меч на 2 клетки с флагом", "sword", true, 2, 0, false},
+		{"меч по дальней диагонали с флагом", "sword", true, 2, 2, false},
+		{"копьё рядом", "spear", false, -1, 0, true},
+		{"копьё на 2 клетки", "spear", false, 2, 0, true},
+		{"копьё на 2 клетки вверх", "spear", false, 0, -2, true},
+		{"копьё на 3 клетки", "spear", false, 3, 0, false},
+		{"копьё на 3 клетки вниз", "spear", false, 0, 3, false},
+		{"копьё по диагонали", "spear", false, 1, 1, false},
+		{"копьё по диагонали с флагом", "spear", true, 1, 1, false},
+		{"своя клетка", "sword", true, 0, 0, false},
+	}
+	for _, c := range cases {
+		ws := weaponStats[c.weapon]
+		// флаг распространяется только на меч, как в StartServer
+		if c.diagonal && c.weapon == "sword" {
+			ws = withDiagonals(ws)
+		}
+		if got := canWeaponHit(ws, c.dx, c.dy); got != c.want {
+			t.Errorf("%s (%d, %d): canWeaponHit = %v, want %v", c.name, c.dx, c.dy, got, c.want)
+		}
+	}
+}
+
+// TestJoinColorRace – одновременные подключения с одним и тем же цветом: цвет достаётся
+// ровно одному, остальные получают отказ, и у принятых игроков цвета не совпадают
+func TestJoinColorRace(t *testing.T) {
+	resetState(t)
+	srv := httptest.NewServer(http.HandlerFunc(wsHandler))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	const clients = 8
+	color := map[string]any{"r": 10, "g": 20, "b": 30, "a": 255}
+
+	var wg sync.WaitGroup
+	var resMu sync.Mutex
+	var accepted []Color
+	rejected := 0
+	start := make(chan struct{})
+	conns := make([]*websocket.Conn, clients)
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, _, err := websocket.DefaultDialer.Dial(url, nil)
+			if err != nil {
+				t.Errorf("подключение %d: %v", i, err)
+				return
+			}
+			conns[i] = c
+			<-start
+			if err := c.WriteJSON(map[string]any{"name": fmt.Sprintf("p%d", i), "color": color}); err != nil {
+				t.Errorf("приветствие %d: %v", i, err)
+				return
+			}
+			c.SetReadDeadline(time.Now().Add(5 * time.Second))
+			for {
+				var msg struct {
+					Type  string `json:"type"`
+					Error string `json:"error"`
+					Color Color  `json:"color"`
+				}
+				if err := c.ReadJSON(&msg); err != nil {
+					t.Errorf("ответ %d: %v", i, err)
+					return
+				}
+				resMu.Lock()
+				switch {
+				case msg.Error != "":
+					rejected++
+				case msg.Type == "init":
+					accepted = append(accepted, msg.Color)
+				default:
+					resMu.Unlock()
+					continue
+				}
+				resMu.Unlock()
+				return
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	for _, c := range conns {
+		if c != nil {
+			c.Close()
+		}
+	}
+
+	if len(accepted) != 1 || rejected != clients-1 {
+		t.Fatalf("принято %d, отклонено %d; want 1 и %d", len(accepted), rejected, clients-1)
+	}
+	if want := (Color{R: 10, G: 20, B: 30, A: 255}); accepted[0] != want {
+		t.Errorf("цвет принятого игрока %+v, want %+v", accepted[0], want)
+	}
+}
+
+// TestUnstickPocket – игрок в клетке, со всех сторон обнесённой камнями, переносится
+// в ближайшую клетку, из которой есть ход
+func TestUnstickPocket(t *testing.T) {
+	resetState(t)
+	mu.Lock()
+	for y := range gameMap {
+		for x := range gameMap[y] {
+			gameMap[y][x] = 0
+		}
+	}
+	const px, py = 10, 10
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx != 0 || dy != 0 {
+				gameMap[py+dy][px+dx] = 2
+			}
+		}
+	}
+	p := &Player{ID: "stuck", Name: "stuck", X: tileCenter(px), Y: tileCenter(py), HP: maxHP}
+	p.TargetX, p.TargetY = p.X, p.Y
+	players[p.ID] = p
+	stuck := isStuck(p)
+	mu.Unlock()
+	if !stuck {
+		t.Fatal("игрок в закрытой клетке не считается застрявшим")
+	}
+
+	turnMu.Lock()
+	playersOrder = []string{p.ID}
+	currentTurn = 0
+	turnStartTime = time.Now()
+	turnMu.Unlock()
+
+	unstickCurrent()
+
+	mu.RLock()
+	defer mu.RUnlock()
+	tx, ty := int(p.X/tileSize), int(p.Y/tileSize)
+	if tx == px && ty == py {
+		t.Fatalf("игрок остался в закрытой клетке (%d, %d)", tx, ty)
+	}
+	if !isPositionValid(p.X, p.Y) || !hasExit(tx, ty) || isStuck(p) {
+		t.Errorf("игрок перенесён в (%d, %d), но оттуда нет хода", tx, ty)
+	}
+	if p.TargetX != p.X || p.TargetY != p.Y {
+		t.Errorf("цель (%v, %v) не совпадает с новой позицией (%v, %v)", p.TargetX, p.TargetY, p.X, p.Y)
+	}
+	if d := max(abs(tx-px), abs(ty-py)); d != 2 {
+		t.Errorf("игрок перенесён на %d клеток, ближайшая свободная клетка – в 2", d)
+	}
+}
+
+// addTurnPlayers добавляет n живых игроков p0..p(n-1) без соединений и ставит их в очередь ходов
+func addTurnPlayers(t *testing.T, n, current int) map[string]*Player {
+	t.Helper()
+	resetState(t)
+	added := make(map[string]*Player, n)
+	order := make([]string, 0, n)
+	mu.Lock()
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("p%d", i)
+		p := &Player{ID: id, Name: id, HP: maxHP, Color: Color{R: uint8(i + 1), A: 255}}
+		players[id] = p
+		playerNames[id] = id
+		added[id] = p
+		order = append(order, id)
+	}
+	mu.Unlock()
+
+	turnMu.Lock()
+	playersOrder = order
+	currentTurn = current
+	turnStartTime = time.Now()
+	turnMu.Unlock()
+	return added
+}
+
+// checkTurnInvariant – текущий ход указывает на игрока из очереди (или очередь пуста),
+// а nextTurn после удаления не паникует и оставляет индекс в пределах очереди
+func checkTurnInvariant(t *testing.T, step string) {
+	t.Helper()
+	turnMu.Lock()
+	defer turnMu.Unlock()
+	for i := 0; i < 2; i++ {
+		id := currentTurnID()
+		if len(playersOrder) == 0 {
+			if id != "" {
+				t.Errorf("%s: очередь пуста, а ход у %q", step, id)
+			}
+		} else if !slices.Contains(playersOrder, id) {
+			t.Errorf("%s: ход у %q, которого нет в очереди %v", step, id, playersOrder)
+		}
+		nextTurn()
+		if len(playersOrder) > 0 && (currentTurn < 0 || currentTurn >= len(playersOrder)) {
+			t.Errorf("%s: после nextTurn индекс %d вне очереди из %d", step, currentTurn, len(playersOrder))
+		}
+	}
+}
+
+// TestTurnOrderRemoval удаляет игроков из очереди в неудобном порядке: текущего,
+// последнего, единственного, при устаревшем индексе хода – и через выход, и через гибель
+func TestTurnOrderRemoval(t *testing.T) {
+	type op struct {
+		id   string
+		kill bool // markDead вместо removePlayer
+	}
+	cases := []struct {
+		name    string
+		players int
+		current int
+		ops     []op
+	}{
+		{"текущий в середине", 3, 1, []op{{"p1", false}}},
+		{"последний, он же текущий", 3, 2, []op{{"p2", false}}},
+		{"последний, он же текущий, гибнет", 3, 2, []op{{"p2", true}}},
+		{"единственный", 1, 0, []op{{"p0", false}}},
+		{"единственный гибнет", 1, 0, []op{{"p0", true}}},
+		{"все с конца", 4, 3, []op{{"p3", false}, {"p2", false}, {"p1", false}, {"p0", false}}},
+		{"все с начала", 4, 0, []op{{"p0", false}, {"p1", true}, {"p2", false}, {"p3", true}}},
+		{"перед текущим", 4, 3, []op{{"p0", false}, {"p1", true}}},
+		{"устаревший индекс", 3, 7, []op{{"p0", false}, {"p2", true}}},
+		{"отрицательный индекс", 3, -1, []op{{"p1", true}, {"p0", false}}},
+		{"устаревший индекс, единственный", 1, 5, []op{{"p0", false}}},
+		{"вперемешку", 5, 4, []op{{"p4", true}, {"p0", false}, {"p2", true}, {"p3", false}, {"p1", false}}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// первое удаление идёт при исходном, возможно устаревшем, индексе хода
+			ps := addTurnPlayers(t, c.players, c.current)
+			for _, o := range c.ops {
+				step := "выход " + o.id
+				if o.kill {
+					step = "гибель " + o.id
+					mu.Lock()
+					markDead(ps[o.id])
+					mu.Unlock()
+				} else {
+					removePlayer(ps[o.id], false)
+				}
+				turnMu.RLock()
+				inOrder := slices.Contains(playersOrder, o.id)
+				turnMu.RUnlock()
+				if inOrder {
+					t.Errorf("%s: игрок остался в очереди", step)
+				}
+				checkTurnInvariant(t, step)
+			}
+		})
+	}
+}
+
+func TestOverwatchEnterReach(t *testing.T) {
+	cases := []struct {
+		name     string
+		from, to [2]int // клетки идущего; копейщик в дозоре стоит в (10, 10)
+		shoots   bool
+	}{
+		{"входит в досягаемость", [2]int{13, 10}, [2]int{12, 10}, true},
+		{"уже внутри, шаг внутри", [2]int{12, 10}, [2]int{11, 10}, false},
+		{"уже внутри, шаг наружу", [2]int{12, 10}, [2]int{13, 10}, false},
+		{"мимо досягаемости", [2]int{13, 11}, [2]int{12, 11}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ps := addTurnPlayers(t, 2, 1)
+			mu.Lock()
+			defer mu.Unlock()
+			for y := range gameMap {
+				for x := range gameMap[y] {
+					gameMap[y][x] = 0
+				}
+			}
+			watcher, mover := ps["p0"], ps["p1"]
+			watcher.Weapon, watcher.Overwatch = "spear", true
+			watcher.X, watcher.Y = tileCenter(10), tileCenter(10)
+			mover.Weapon = "sword"
+			mover.X, mover.Y = tileCenter(c.from[0]), tileCenter(c.from[1])
+
+			shooter := overwatchShooter(mover, c.to[0], c.to[1])
+			if got := shooter != nil; got != c.shoots {
+				t.Fatalf("выстрел из дозора = %v, ожидалось %v", got, c.shoots)
+			}
+			if watcher.Overwatch == c.shoots {
+				t.Errorf("дозор после шага = %v, ожидалось %v", watcher.Overwatch, !c.shoots)
+			}
+		})
+	}
+}
+
+// joinTestClient подключается к серверу под именем name и ждёт init; возвращает соединение и ID
+func joinTestClient(t *testing.T, url, name string) (*websocket.Conn, string) {
+	t.Helper()
+	c, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("подключение %s: %v", name, err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.WriteJSON(map[string]any{"name": name}); err != nil {
+		t.Fatalf("приветствие %s: %v", name, err)
+	}
+	c.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg struct {
+			Type  string `json:"type"`
+			ID    string `json:"id"`
+			Error string `json:"error"`
+		}
+		if err := c.ReadJSON(&msg); err != nil {
+			t.Fatalf("ответ %s: %v", name, err)
+		}
+		if msg.Error != "" {
+			t.Fatalf("%s не принят: %s", name, msg.Error)
+		}
+		if msg.Type == "init" {
+			return c, msg.ID
+		}
+	}
+}
+
+// receivedChat читает сообщения до timeout и сообщает, пришла ли строка чата с текстом text
+func receivedChat(c *websocket.Conn, text string, timeout time.Duration) bool {
+	c.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		var msg struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := c.ReadJSON(&msg); err != nil {
+			return false
+		}
+		if msg.Type == "chat" && msg.Text == text {
+			return true
+		}
+	}
+}
+
+// TestSpectatorChatAfterCleanup – погибший остаётся зрителем и после того, как cleanup
+// убрал его из players: его чат доходит до зрителей и не доходит до живых
+func TestSpectatorChatAfterCleanup(t *testing.T) {
+	resetState(t)
+	srv := httptest.NewServer(http.HandlerFunc(wsHandler))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	deadConn, deadID := joinTestClient(t, url, "dead")
+	otherConn, otherID := joinTestClient(t, url, "watcher")
+	aliveConn, _ := joinTestClient(t, url, "alive")
+
+	mu.Lock()
+	for _, id := range []string{deadID, otherID} {
+		markDead(players[id])
+		players[id].DeathTime = time.Now().Add(-time.Minute)
+	}
+	mu.Unlock()
+
+	cleanup(time.Now())
+	mu.RLock()
+	_, stillListed := players[deadID]
+	mu.RUnlock()
+	if stillListed {
+		t.Fatal("cleanup не убрал давно погибшего игрока")
+	}
+
+	const text = "привет из зрителей"
+	handleChat(deadID, map[string]any{"text": text})
+
+	if !receivedChat(deadConn, text, 2*time.Second) {
+		t.Error("автор-зритель не получил своё сообщение")
+	}
+	if !receivedChat(otherConn, text, 2*time.Second) {
+		t.Error("другой зритель не получил сообщение")
+	}
+	if receivedChat(aliveConn, text, 300*time.Millisecond) {
+		t.Error("живой игрок получил сообщение зрителя")
+	}
+}